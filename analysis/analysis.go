@@ -5,6 +5,7 @@ import (
 
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/bongo227/Furlang/ast"
 	"github.com/bongo227/Furlang/lexer"
@@ -15,6 +16,7 @@ import (
 var (
 	intType   = types.IntType(0)
 	floatType = types.FloatType(0)
+	runeType  = types.IntType(32)
 )
 
 // Analysis checks the semantics of the abstract syntax tree and adds any allowed
@@ -23,6 +25,40 @@ type Analysis struct {
 	root            *ast.Ast
 	currentBlock    *ast.BlockStatement
 	currentFunction *ast.FunctionDeclaration
+	loopDepth       int
+
+	// fallthroughSmt is the fallthrough statement ending the case clause
+	// being analysed, any other fallthrough statement is out of place
+	fallthroughSmt *ast.FallthroughStatement
+
+	// readsMemory is set when the current function reads a global or
+	// dereferences a pointer
+	readsMemory bool
+
+	// closures are the lambdas enclosing the current block, lambdas counts
+	// every lambda so each can be given a unique name
+	closures []*closure
+	lambdas  int
+
+	// typeArguments are the types substituted for the type parameters of the
+	// generic procedure being instantiated
+	typeArguments map[string]types.Type
+}
+
+// Error represents an error in the analysis package
+type Error struct {
+	Message string
+}
+
+func (a *Analysis) newError(node ast.Node, message string) *Error {
+	token := node.First()
+	return &Error{
+		Message: fmt.Sprintf("%d:%d: %s", token.Line(), token.Column(), message),
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
 }
 
 func NewAnalysis(root *ast.Ast) *Analysis {
@@ -34,13 +70,94 @@ func NewAnalysis(root *ast.Ast) *Analysis {
 func (a *Analysis) Analalize() *ast.Ast {
 	log.Println("Analasis Started")
 
+	a.declarations()
+	a.lengths()
+	a.globals()
+
 	for i, f := range a.root.Functions {
+		// Generic procedures are type checked when they are instantiated
+		if len(f.TypeParameters) > 0 {
+			a.declareGeneric(f)
+			continue
+		}
 		a.root.Functions[i] = a.functionDcl(f).(*ast.FunctionDeclaration)
 	}
 
+	// Replace constant expressions with their values
+	for _, f := range a.root.Functions {
+		for _, instance := range f.Instances {
+			a.foldStatement(instance.Body)
+		}
+		if len(f.TypeParameters) == 0 {
+			a.foldStatement(f.Body)
+		}
+	}
+
 	return a.root
 }
 
+// declarations registers every top level declaration in the root scope before
+// any bodies are analysed, so declarations can be used before they appear
+func (a *Analysis) declarations() {
+	declared := make(map[string]bool)
+	for _, f := range a.root.Functions {
+		if f.Receiver != nil {
+			a.declareMethod(f)
+			continue
+		}
+
+		name := f.Name.Value.Value()
+		if declared[name] {
+			panic(a.newError(f, fmt.Sprintf("proc %q redeclared", name)))
+		}
+		declared[name] = true
+
+		if a.root.Scope != nil {
+			a.root.Scope.Insert(name, f)
+		}
+	}
+
+	for _, dcl := range a.root.Types {
+		name := dcl.Name.Value.Value()
+		if declared[name] {
+			panic(a.newError(dcl, fmt.Sprintf("%q redeclared", name)))
+		}
+		declared[name] = true
+	}
+
+	for _, dcl := range a.root.Globals {
+		var name string
+		switch dcl := dcl.(type) {
+		case *ast.VaribleDeclaration:
+			name = dcl.Name.Value.Value()
+		case *ast.ConstantDeclaration:
+			name = dcl.Name.Value.Value()
+		}
+
+		if declared[name] {
+			panic(a.newError(dcl, fmt.Sprintf("%q redeclared", name)))
+		}
+		declared[name] = true
+	}
+}
+
+// globals runs analysis on the package level declarations in the root scope
+func (a *Analysis) globals() {
+	if a.root.Scope != nil {
+		a.currentBlock = &ast.BlockStatement{Scope: a.root.Scope}
+	}
+
+	for i, dcl := range a.root.Globals {
+		newDcl := a.declare(dcl)
+		if varibleDcl, ok := newDcl.(*ast.VaribleDeclaration); ok {
+			varibleDcl.Value = a.fold(varibleDcl.Value)
+		}
+		a.root.Globals[i] = newDcl
+	}
+
+	a.currentBlock = nil
+}
+
 // Gets the type of a node
 func (a *Analysis) typ(node ast.Node) types.Type {
 	switch node := node.(type) {
@@ -50,22 +167,35 @@ func (a *Analysis) typ(node ast.Node) types.Type {
 			return intType
 		case lexer.FLOAT:
 			return floatType
+		case lexer.CHAR:
+			return runeType
+		case lexer.STRING:
+			return types.BasicString
 		}
 		panic(fmt.Sprintf("Unregcognized literal type: %s", node.Value.Type().String()))
 
 	case *ast.BinaryExpression:
-		lType := a.typ(node.Left)
-		rType := a.typ(node.Right)
-		if lType == floatType || rType == floatType {
-			return floatType
+		if comparison(node.Operator.Type()) {
+			return types.BasicBool
 		}
-		return intType
+		return a.binaryOperandType(node)
 
 	case *ast.CallExpression:
+		if name, ok := a.builtin(node); ok {
+			return a.builtinTyp(name, node)
+		}
+
+		if len(node.TypeArguments) > 0 {
+			return a.genericReturn(node)
+		}
+
 		switch nodeType := a.typ(node.Function).(type) {
 		case *types.Function:
 			return nodeType.Return()
-		case *types.Basic:
+		case *types.Basic, *types.Named:
+			return nodeType
+		case *types.Union:
+			// Variant with fields
 			return nodeType
 		}
 
@@ -73,32 +203,103 @@ func (a *Analysis) typ(node ast.Node) types.Type {
 		return nil
 
 	case *ast.CastExpression:
+		return a.resolve(node.Type)
+
+	case *ast.CompositionExpression:
 		return node.Type
 
+	case *ast.LambdaExpression:
+		return a.typ(node.Function)
+
+	case *ast.TypeExpression:
+		return a.resolve(node.Type)
+
+	case *ast.UnaryExpression:
+		switch node.Operator.Type() {
+		case lexer.AND:
+			return types.NewPointer(a.typ(node.Expression))
+		case lexer.MUL:
+			pointer, ok := a.typ(node.Expression).(*types.Pointer)
+			if !ok {
+				panic(a.newError(node, "cannot dereference non-pointer"))
+			}
+			return pointer.Type()
+		}
+		return a.typ(node.Expression)
+
 	case *ast.IdentExpression:
 		ident := node.Value.Value()
 		log.Printf("Looking for %q in scope", ident)
 
 		// Check if ident is type
-		typ := types.GetType(ident)
-		if typ != nil {
+		if typ, ok := a.typeArguments[ident]; ok {
 			return typ
 		}
 
-		return a.typ(a.currentBlock.Scope.Lookup(ident))
+		return a.typ(a.lookup(ident))
 
 	case *ast.IndexExpression:
-		return a.typ(node.Expression).Base()
+		// Arrays are indexed through pointers automaticly
+		typ := a.typ(node.Expression)
+		if pointer, ok := typ.(*types.Pointer); ok {
+			typ = pointer.Type()
+		}
+		return typ.Base()
 
 	case *ast.BraceLiteralExpression:
-		return node.Type
+		return a.resolve(node.Type)
+
+	case *ast.ParenLiteralExpression:
+		elementTypes := make([]types.Type, len(node.Elements))
+		for i, element := range node.Elements {
+			elementTypes[i] = a.typ(element)
+		}
+
+		return types.NewTuple(elementTypes...)
 
 	case *ast.VaribleDeclaration:
 		if node.Type != nil {
-			return node.Type
+			return a.resolve(node.Type)
 		}
 		return a.typ(node.Value)
 
+	case *ast.ConstantDeclaration:
+		if node.Type != nil {
+			return a.resolve(node.Type)
+		}
+		return a.typ(node.Value)
+
+	case *ast.SelectorExpression:
+		if union := a.unionType(node.Expression); union != nil {
+			return union
+		}
+		typ := a.typ(node.Expression)
+		if structure, index := field(node, typ); index >= 0 {
+			return structure.Types()[index]
+		}
+		return a.method(node, typ).Type
+
+	case *ast.FieldExpression:
+		return node.Type
+
+	case *ast.MethodCallExpression:
+		return node.Method.Type.Return()
+
+	case *ast.InterfaceExpression:
+		return node.Type
+
+	case *ast.VariantExpression:
+		return node.Type
+
+	case *ast.MatchExpression:
+		return node.Type
+
+	case *ast.TypeDeclaration:
+		return node.Value
+
+	case *ast.Builtin:
+		return node.Type
+
 	case *ast.FunctionDeclaration:
 		argTypes := make([]types.Type, len(node.Arguments))
 		for i, arg := range node.Arguments {
@@ -131,15 +332,43 @@ func (a *Analysis) functionDcl(node *ast.FunctionDeclaration) ast.Declare {
 	newFunctionDcl := &ast.FunctionDeclaration{}
 
 	a.currentFunction = node
+	a.readsMemory = false
 
 	newFunctionDcl.Name = node.Name
-	newFunctionDcl.Arguments = node.Arguments
+	newFunctionDcl.Arguments = a.arguments(node.Arguments)
 	newFunctionDcl.Body = a.blockSmt(node.Body).(*ast.BlockStatement)
-	newFunctionDcl.Return = node.Return
+	newFunctionDcl.Return = a.resolve(node.Return)
+	newFunctionDcl.Pure = node.Pure
+	newFunctionDcl.ReadOnly = node.Pure && a.readsMemory
+	newFunctionDcl.Receiver = node.Receiver
+
+	// Procedures with a return value must not fall off the end of the body
+	if node.Return != nil && !terminates(node.Body) {
+		panic(a.newError(node, fmt.Sprintf("missing return at end of proc %q",
+			node.Name.Value.Value())))
+	}
 
 	return newFunctionDcl
 }
 
+// arguments returns the arguments with the type arguments of the instance
+// being analysed substituted
+func (a *Analysis) arguments(nodes []*ast.ArgumentDeclaration) []*ast.ArgumentDeclaration {
+	if len(a.typeArguments) == 0 {
+		return nodes
+	}
+
+	arguments := make([]*ast.ArgumentDeclaration, len(nodes))
+	for i, arg := range nodes {
+		arguments[i] = &ast.ArgumentDeclaration{
+			Name: arg.Name,
+			Type: a.resolve(arg.Type),
+		}
+	}
+
+	return arguments
+}
+
 // varible runs analysis on the type and value of a varible declaration
 func (a *Analysis) varibleDcl(node *ast.VaribleDeclaration) ast.Declare {
 	newVaribleDcl := &ast.VaribleDeclaration{}
@@ -150,13 +379,24 @@ func (a *Analysis) varibleDcl(node *ast.VaribleDeclaration) ast.Declare {
 	log.Println(a.typ(node.Value))
 
 	if node.Type == nil {
-		newVaribleDcl.Type = a.typ(node.Value)
 		newVaribleDcl.Value = a.expression(node.Value)
+		newVaribleDcl.Type = a.typ(newVaribleDcl.Value)
+		if newVaribleDcl.Type == nil {
+			panic(a.newError(node, fmt.Sprintf("proc with no return value used as value for %q",
+				node.Name.Value.Value())))
+		}
 	} else {
-		newVaribleDcl.Type = node.Type
-		newVaribleDcl.Value = &ast.CastExpression{
-			Type:       node.Type,
-			Expression: a.expression(node.Value),
+		newVaribleDcl.Type = a.resolve(node.Type)
+		value := a.expression(node.Value)
+		if neverCast(newVaribleDcl.Type) || distinct(newVaribleDcl.Type) || distinct(a.typ(value)) ||
+			boolean(newVaribleDcl.Type) || boolean(a.typ(value)) ||
+			textual(newVaribleDcl.Type) || textual(a.typ(value)) {
+			newVaribleDcl.Value = a.convert(value, newVaribleDcl.Type)
+		} else {
+			newVaribleDcl.Value = &ast.CastExpression{
+				Type:       newVaribleDcl.Type,
+				Expression: value,
+			}
 		}
 	}
 
@@ -167,12 +407,48 @@ func (a *Analysis) varibleDcl(node *ast.VaribleDeclaration) ast.Declare {
 	return newVaribleDcl
 }
 
+// tupleDcl runs analysis on a destructuring declaration, each varible takes the
+// type of the matching tuple element
+func (a *Analysis) tupleDcl(node *ast.TupleDeclaration) ast.Declare {
+	newTupleDcl := &ast.TupleDeclaration{}
+
+	newTupleDcl.Define = node.Define
+	newTupleDcl.Value = a.expression(node.Value)
+
+	valueType := a.typ(newTupleDcl.Value)
+	tuple, ok := valueType.(*types.Tuple)
+	if !ok || len(tuple.Types()) != len(node.Declarations) {
+		panic(a.newError(node, fmt.Sprintf("cannot assign %v to %d varibles",
+			valueType, len(node.Declarations))))
+	}
+
+	newTupleDcl.Declarations = make([]*ast.VaribleDeclaration, len(node.Declarations))
+	for i, dcl := range node.Declarations {
+		newVaribleDcl := &ast.VaribleDeclaration{
+			Name: dcl.Name,
+			Type: tuple.Types()[i],
+		}
+
+		if a.currentBlock != nil {
+			a.currentBlock.Scope.Replace(dcl.Name.Value.Value(), newVaribleDcl)
+		}
+
+		newTupleDcl.Declarations[i] = newVaribleDcl
+	}
+
+	return newTupleDcl
+}
+
 func (a *Analysis) declare(node ast.Declare) ast.Declare {
 	switch node := (node).(type) {
 	case *ast.VaribleDeclaration:
 		return a.varibleDcl(node)
 	case *ast.FunctionDeclaration:
 		return a.functionDcl(node)
+	case *ast.TupleDeclaration:
+		return a.tupleDcl(node)
+	case *ast.ConstantDeclaration:
+		return a.constantDcl(node)
 	default:
 		log.Printf("Unhandled %q node\n", reflect.TypeOf(node).String())
 	}
@@ -192,6 +468,21 @@ func (a *Analysis) statement(node ast.Statement) ast.Statement {
 		return a.blockSmt(node)
 	case *ast.ReturnStatement:
 		return a.returnSmt(node)
+	case *ast.SwitchStatement:
+		return a.switchSmt(node)
+	case *ast.MatchStatement:
+		return a.matchSmt(node)
+	case *ast.FallthroughStatement:
+		if node != a.fallthroughSmt {
+			panic(a.newError(node, "fallthrough statement out of place"))
+		}
+		return node
+	case *ast.DeferStatement:
+		return a.deferSmt(node)
+	case *ast.ExpressionStatement:
+		return &ast.ExpressionStatement{
+			Expression: a.expression(node.Expression),
+		}
 	case *ast.DeclareStatement:
 		return &ast.DeclareStatement{
 			Statement: a.declare(node.Statement),
@@ -211,6 +502,22 @@ func (a *Analysis) expression(node ast.Expression) ast.Expression {
 		return a.callExp(node)
 	case *ast.BraceLiteralExpression:
 		return a.braceLiteralExp(node)
+	case *ast.ParenLiteralExpression:
+		return a.parenLiteralExp(node)
+	case *ast.UnaryExpression:
+		return a.unaryExp(node)
+	case *ast.IndexExpression:
+		return a.indexExp(node)
+	case *ast.LiteralExpression:
+		return a.literalExp(node)
+	case *ast.IdentExpression:
+		return a.identExp(node)
+	case *ast.LambdaExpression:
+		return a.lambdaExp(node)
+	case *ast.SelectorExpression:
+		return a.selectorExp(node)
+	case *ast.MatchExpression:
+		return a.matchExp(node)
 	default:
 		log.Printf("Unhandled %q node\n", reflect.TypeOf(node).String())
 	}
@@ -218,18 +525,166 @@ func (a *Analysis) expression(node ast.Expression) ast.Expression {
 	return node
 }
 
+func (a *Analysis) unaryExp(node *ast.UnaryExpression) ast.Expression {
+	// Negated integer literals are range checked with their sign, so the
+	// smallest i64 can be written
+	if literal, ok := node.Expression.(*ast.LiteralExpression); ok && node.Operator.Type() == lexer.SUB &&
+		literal.Value.Type() == lexer.INT {
+		return a.literalExp(&ast.LiteralExpression{
+			Value: lexer.NewToken(lexer.INT, "-"+literal.Value.Value(), node.Operator.Line(), node.Operator.Column()),
+		})
+	}
+
+	newUnaryExp := &ast.UnaryExpression{
+		Operator:   node.Operator,
+		Expression: a.expression(node.Expression),
+	}
+
+	switch node.Operator.Type() {
+	case lexer.AND:
+		if !a.addressable(node.Expression) {
+			panic(a.newError(node, "cannot take the address of a non-addressable expression"))
+		}
+	case lexer.MUL:
+		if _, ok := a.typ(newUnaryExp.Expression).(*types.Pointer); !ok {
+			panic(a.newError(node, fmt.Sprintf("cannot dereference non-pointer type %s",
+				a.typ(newUnaryExp.Expression))))
+		}
+		a.readsMemory = true
+	case lexer.ADD, lexer.SUB:
+		if typ := a.typ(newUnaryExp.Expression); !numeric(typ) {
+			panic(a.newError(node, fmt.Sprintf("operator %s not defined on %s", node.Operator.Type(), typ)))
+		}
+	}
+
+	return newUnaryExp
+}
+
+// identExp substitutes the value of constants
+func (a *Analysis) identExp(node *ast.IdentExpression) ast.Expression {
+	decl := a.lookup(node.Value.Value())
+	if decl == nil {
+		if _, ok := a.typeArguments[node.Value.Value()]; !ok {
+			panic(a.newError(node, fmt.Sprintf("undefined: %s", node.Value.Value())))
+		}
+		return node
+	}
+
+	if constDcl, ok := decl.(*ast.ConstantDeclaration); ok {
+		return constDcl.Value
+	}
+
+	// Types and predeclared constants arent stored anywhere
+	switch decl := decl.(type) {
+	case *ast.TypeDeclaration:
+		return node
+	case *ast.Builtin:
+		if decl.Type == nil {
+			panic(a.newError(node, fmt.Sprintf("builtin %q must be called", decl.Name)))
+		}
+		return node
+	}
+
+	if f, ok := decl.(*ast.FunctionDeclaration); ok && len(f.TypeParameters) > 0 {
+		panic(a.newError(node, fmt.Sprintf("cannot use generic proc %q without calling it",
+			node.Value.Value())))
+	}
+
+	if a.isGlobal(decl) {
+		a.readsMemory = true
+	}
+	a.capture(node.Value, decl)
+
+	return node
+}
+
+func (a *Analysis) literalExp(node *ast.LiteralExpression) ast.Expression {
+	switch node.Value.Type() {
+	case lexer.INT:
+		if _, err := strconv.ParseInt(node.Value.Value(), 0, 64); err != nil {
+			panic(a.newError(node, fmt.Sprintf("integer constant %s overflows int", node.Value.Value())))
+		}
+	case lexer.FLOAT:
+		if _, err := strconv.ParseFloat(node.Value.Value(), 64); err != nil {
+			panic(a.newError(node, fmt.Sprintf("floating-point constant %s overflows float", node.Value.Value())))
+		}
+	}
+
+	// Runes are integer constants of type rune
+	if node.Value.Type() == lexer.CHAR {
+		value, _ := a.evaluate(node)
+		return literal(value, node.Value)
+	}
+
+	return node
+}
+
+func (a *Analysis) indexExp(node *ast.IndexExpression) ast.Expression {
+	newIndexExp := &ast.IndexExpression{
+		Expression: a.expression(node.Expression),
+		LeftBrack:  node.LeftBrack,
+		Index:      a.expression(node.Index),
+		RightBrack: node.RightBrack,
+	}
+
+	// Constant indexes into arrays are checked at compile time
+	typ := a.typ(newIndexExp.Expression)
+	switch typ.(type) {
+	case *types.Pointer, *types.Slice:
+		a.readsMemory = true
+	}
+	if pointer, ok := typ.(*types.Pointer); ok {
+		typ = pointer.Type()
+	}
+	if array, ok := typ.(*types.Array); ok {
+		if index, ok := a.evaluate(newIndexExp.Index); ok && !index.isFloat() && (index.i < 0 || index.i >= int64(array.Length())) {
+			panic(a.newError(node.Index, fmt.Sprintf("index %d out of bounds for %s", index.i, array)))
+		}
+	}
+
+	return newIndexExp
+}
+
+// addressable returns true if the expression refers to a location in memory
+func (a *Analysis) addressable(node ast.Expression) bool {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		_, ok := a.currentBlock.Scope.Lookup(node.Value.Value()).(*ast.VaribleDeclaration)
+		return ok
+	case *ast.IndexExpression:
+		return a.addressable(node.Expression)
+	case *ast.UnaryExpression:
+		return node.Operator.Type() == lexer.MUL
+	case *ast.SelectorExpression:
+		return a.unionType(node.Expression) == nil && a.addressable(node.Expression)
+	}
+
+	return false
+}
+
 func (a *Analysis) braceLiteralExp(node *ast.BraceLiteralExpression) ast.Expression {
-	newBraceLiteralExp := &ast.BraceLiteralExpression{}
+	if structure, ok := node.Type.(*types.Struct); ok {
+		return a.structLiteralExp(node, structure)
+	}
 
-	newBraceLiteralExp.Type = node.Type
+	newBraceLiteralExp := &ast.BraceLiteralExpression{
+		LeftBrace:  node.LeftBrace,
+		RightBrace: node.RightBrace,
+	}
+
+	newBraceLiteralExp.Type = a.resolve(node.Type)
+	if array, ok := newBraceLiteralExp.Type.(*types.Array); ok && len(node.Elements) > array.Length() {
+		panic(a.newError(node, fmt.Sprintf("%d values in %s literal", len(node.Elements), array)))
+	}
 
 	newBraceLiteralExp.Elements = make([]ast.Expression, len(node.Elements))
 	for i, elm := range node.Elements {
 		exp := a.expression(elm)
 		// TODO: add non-reflect equal check
-		if !reflect.DeepEqual(a.typ(exp), node.Type.Base()) {
+		if !reflect.DeepEqual(a.typ(exp), newBraceLiteralExp.Type.Base()) {
+			a.castable(elm, a.typ(exp), newBraceLiteralExp.Type.Base())
 			exp = &ast.CastExpression{
-				Type:       node.Type.Base(),
+				Type:       newBraceLiteralExp.Type.Base(),
 				Expression: exp,
 			}
 		}
@@ -239,21 +694,233 @@ func (a *Analysis) braceLiteralExp(node *ast.BraceLiteralExpression) ast.Express
 	return newBraceLiteralExp
 }
 
+// terminates returns true if control can not flow past the end of the statement
+func terminates(node ast.Statement) bool {
+	switch node := node.(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.BlockStatement:
+		if len(node.Statements) == 0 {
+			return false
+		}
+		return terminates(node.Statements[len(node.Statements)-1])
+	case *ast.IfStatment:
+		// Every branch must terminate, including a final else
+		for ; node != nil; node = node.Else {
+			if node.Condition != nil && node.Else == nil {
+				return false
+			}
+			if !terminates(node.Body) {
+				return false
+			}
+		}
+		return true
+	case *ast.SwitchStatement:
+		// Every clause must terminate (or fallthrough), including a default
+		hasDefault := false
+		for _, clause := range node.Clauses {
+			if clause.IsDefault() {
+				hasDefault = true
+			}
+			if !clause.Fallthrough() && !terminates(clause.Body) {
+				return false
+			}
+		}
+		return hasDefault
+	case *ast.MatchStatement:
+		// Analysis checks every variant is handled
+		for _, clause := range node.Clauses {
+			if !terminates(clause.Body) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func (a *Analysis) parenLiteralExp(node *ast.ParenLiteralExpression) ast.Expression {
+	newParenLiteralExp := &ast.ParenLiteralExpression{}
+
+	newParenLiteralExp.LeftParen = node.LeftParen
+	newParenLiteralExp.RightParen = node.RightParen
+
+	newParenLiteralExp.Elements = make([]ast.Expression, len(node.Elements))
+	for i, element := range node.Elements {
+		newParenLiteralExp.Elements[i] = a.expression(element)
+	}
+
+	return newParenLiteralExp
+}
+
+// sameType returns true if no cast is needed between the types, basic types
+// match if they have the same llvm representation
+func sameType(a, b types.Type) bool {
+	aBasic, aIsBasic := a.(*types.Basic)
+	bBasic, bIsBasic := b.(*types.Basic)
+	if aIsBasic && bIsBasic {
+		return aBasic.Llvm() == bBasic.Llvm()
+	}
+
+	aFunction, aIsFunction := a.(*types.Function)
+	bFunction, bIsFunction := b.(*types.Function)
+	if aIsFunction && bIsFunction {
+		return sameFunction(aFunction, bFunction)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// sameFunction returns true if the function types have the same arguments and
+// return type
+func sameFunction(a, b *types.Function) bool {
+	if len(a.Arguments()) != len(b.Arguments()) {
+		return false
+	}
+	for i, arg := range a.Arguments() {
+		if !sameType(arg, b.Arguments()[i]) {
+			return false
+		}
+	}
+
+	if a.Return() == nil || b.Return() == nil {
+		return a.Return() == b.Return()
+	}
+	return sameType(a.Return(), b.Return())
+}
+
+// neverCast returns true if values of the type are never cast, function
+// values, unions and interfaces must already have the type they are used as
+func neverCast(typ types.Type) bool {
+	switch typ.(type) {
+	case *types.Function, *types.Union, *types.Interface, *types.Array:
+		return true
+	}
+
+	return false
+}
+
+// boolean returns true if the underlying type of the type is bool
+func boolean(typ types.Type) bool {
+	basic, ok := types.Underlying(typ).(*types.Basic)
+	return ok && basic.Info()&types.IsBool != 0
+}
+
+// textual returns true if the underlying type of the type is string
+func textual(typ types.Type) bool {
+	basic, ok := types.Underlying(typ).(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+// convertible returns true if a value of one type can be explicitly converted
+// to the other, integers convert to the string holding the rune they encode
+// and strings convert to the first rune they hold
+func convertible(from, to types.Type) bool {
+	from, to = types.Underlying(from), types.Underlying(to)
+	switch {
+	case boolean(from) != boolean(to):
+		return false
+	case textual(to) && !textual(from):
+		basic, ok := from.(*types.Basic)
+		return ok && basic.Info()&types.IsInt != 0
+	case textual(from) && !textual(to):
+		basic, ok := to.(*types.Basic)
+		return ok && basic.Type() == types.Rune
+	}
+
+	return true
+}
+
+// distinct returns true for named types declared with another type, values are
+// only converted implicitly to or from them if they are constant
+func distinct(typ types.Type) bool {
+	_, ok := typ.(*types.Named)
+	return ok
+}
+
+// castable reports an error if a value of one type cant be cast to the other
+// implicitly, only constants are converted to or from distinct named types
+func (a *Analysis) castable(node ast.Node, from, to types.Type) {
+	if distinct(from) || distinct(to) {
+		exp, ok := node.(ast.Expression)
+		if _, constant := a.evaluate(exp); !ok || !constant {
+			panic(a.newError(node, fmt.Sprintf("cannot use %v as %v without a conversion", from, to)))
+		}
+		from, to = types.Underlying(from), types.Underlying(to)
+	}
+
+	if neverCast(from) || neverCast(to) || boolean(from) != boolean(to) || textual(from) != textual(to) {
+		panic(a.newError(node, fmt.Sprintf("cannot use %v as %v", from, to)))
+	}
+}
+
+// convert casts the expression to the type if they dont match, tuple literals
+// are converted element by element
+func (a *Analysis) convert(node ast.Expression, typ types.Type) ast.Expression {
+	expType := a.typ(node)
+	if reflect.DeepEqual(expType, typ) {
+		return node
+	}
+
+	if _, ok := typ.(*types.Function); ok && sameType(expType, typ) {
+		return node
+	}
+
+	if iface, ok := typ.(*types.Interface); ok {
+		return a.implement(node, expType, iface)
+	}
+
+	tuple, isTuple := typ.(*types.Tuple)
+	expTuple, expIsTuple := expType.(*types.Tuple)
+	if !isTuple && !expIsTuple {
+		a.castable(node, expType, typ)
+		log.Printf("Casting %q to %q\n", expType, typ)
+		return &ast.CastExpression{
+			Expression: node,
+			Type:       typ,
+		}
+	}
+
+	// Only tuple literals can be converted
+	paren, isParen := node.(*ast.ParenLiteralExpression)
+	if !isTuple || !expIsTuple || !isParen || len(tuple.Types()) != len(expTuple.Types()) {
+		panic(a.newError(node, fmt.Sprintf("cannot use %v as %v", expType, typ)))
+	}
+
+	newParenLiteralExp := &ast.ParenLiteralExpression{
+		LeftParen:  paren.LeftParen,
+		Elements:   make([]ast.Expression, len(paren.Elements)),
+		RightParen: paren.RightParen,
+	}
+	for i, element := range paren.Elements {
+		newParenLiteralExp.Elements[i] = a.convert(element, tuple.Types()[i])
+	}
+
+	return newParenLiteralExp
+}
+
 func (a *Analysis) returnSmt(node *ast.ReturnStatement) ast.Statement {
 	newReturnSmt := &ast.ReturnStatement{}
 
+	// Bare return
+	if node.Result == nil {
+		if a.currentFunction.Return != nil {
+			panic(a.newError(node, fmt.Sprintf("missing return value in proc %q",
+				a.currentFunction.Name.Value.Value())))
+		}
+		return newReturnSmt
+	}
+
+	if a.currentFunction.Return == nil {
+		panic(a.newError(node, fmt.Sprintf("unexpected return value in proc %q with no return type",
+			a.currentFunction.Name.Value.Value())))
+	}
+
 	newReturnSmt.Result = a.expression(node.Result)
 	pp.Print(newReturnSmt.Result)
 
-	resultType := a.typ(newReturnSmt.Result)
-	// TODO: remove reflect
-	if !reflect.DeepEqual(resultType, a.currentFunction.Return) {
-		log.Printf("Casting return statment %q to %q\n", resultType, a.currentFunction.Return)
-		newReturnSmt.Result = &ast.CastExpression{
-			Expression: newReturnSmt.Result,
-			Type:       a.currentFunction.Return,
-		}
-	}
+	newReturnSmt.Result = a.convert(newReturnSmt.Result, a.resolve(a.currentFunction.Return))
 
 	return newReturnSmt
 }
@@ -264,20 +931,59 @@ func (a *Analysis) forSmt(node *ast.ForStatement) ast.Statement {
 	newForSmt := &ast.ForStatement{}
 
 	newForSmt.Index = a.statement(node.Index)
-	newForSmt.Condition = a.expression(node.Condition)
+	newForSmt.Condition = a.condition(node.Condition)
 	newForSmt.Increment = a.statement(node.Increment)
+
+	a.loopDepth++
 	newForSmt.Body = a.blockSmt(node.Body).(*ast.BlockStatement)
+	a.loopDepth--
 
 	return newForSmt
 }
 
+func (a *Analysis) deferSmt(node *ast.DeferStatement) ast.Statement {
+	log.Println("Defer")
+
+	if a.loopDepth > 0 {
+		panic(a.newError(node, "defer inside a for loop is not supported"))
+	}
+
+	if name, ok := a.builtin(node.Call); ok && name != "free" {
+		panic(a.newError(node, fmt.Sprintf("defer discards result of %s", name)))
+	}
+
+	call, ok := a.callExp(node.Call).(*ast.CallExpression)
+	if !ok {
+		panic(a.newError(node, "defer requires a procedure call"))
+	}
+
+	return &ast.DeferStatement{
+		Defer: node.Defer,
+		Call:  call,
+	}
+}
+
+// condition checks the condition of an if or for statement is a bool
+func (a *Analysis) condition(node ast.Expression) ast.Expression {
+	if node == nil {
+		return nil
+	}
+
+	newExp := a.expression(node)
+	if typ := a.typ(newExp); !boolean(typ) {
+		panic(a.newError(node, fmt.Sprintf("non-bool condition of type %s", typ)))
+	}
+
+	return newExp
+}
+
 func (a *Analysis) ifSmt(node *ast.IfStatment) ast.Statement {
 	log.Println("If")
 
 	newIfSmt := &ast.IfStatment{}
 
 	if node.Condition != nil {
-		newIfSmt.Condition = a.expression(node.Condition)
+		newIfSmt.Condition = a.condition(node.Condition)
 	}
 
 	newIfSmt.Body = a.blockSmt(node.Body).(*ast.BlockStatement)
@@ -289,36 +995,203 @@ func (a *Analysis) ifSmt(node *ast.IfStatment) ast.Statement {
 	return newIfSmt
 }
 
+func (a *Analysis) switchSmt(node *ast.SwitchStatement) ast.Statement {
+	log.Println("Switch")
+
+	newSwitchSmt := &ast.SwitchStatement{}
+
+	var tagType types.Type
+	if node.Tag != nil {
+		newSwitchSmt.Tag = a.expression(node.Tag)
+		tagType = a.typ(newSwitchSmt.Tag)
+		if !comparable(tagType) {
+			panic(a.newError(node.Tag, fmt.Sprintf("cannot switch on %s", tagType)))
+		}
+	}
+
+	seen := make(map[int64]bool)
+	hasDefault := false
+
+	newSwitchSmt.Clauses = make([]*ast.CaseClause, len(node.Clauses))
+	for i, clause := range node.Clauses {
+		newClause := &ast.CaseClause{
+			Case:        clause.Case,
+			Expressions: make([]ast.Expression, len(clause.Expressions)),
+			Colon:       clause.Colon,
+		}
+
+		if clause.IsDefault() {
+			if hasDefault {
+				panic(a.newError(clause, "multiple defaults in switch"))
+			}
+			hasDefault = true
+		}
+
+		for j, exp := range clause.Expressions {
+			// Cases of a tagless switch are conditions
+			if node.Tag == nil {
+				newClause.Expressions[j] = a.condition(exp)
+				continue
+			}
+
+			newExp := a.expression(exp)
+
+			// Cast case values to the type of the tag
+			if !reflect.DeepEqual(a.typ(newExp), tagType) {
+				a.castable(exp, a.typ(newExp), tagType)
+				a.representable(newExp, tagType)
+				newExp = &ast.CastExpression{
+					Type:       tagType,
+					Expression: newExp,
+				}
+			}
+
+			// Check for duplicate constant cases once they have the type of the tag
+			if value, ok := a.evaluate(newExp); ok && !value.isFloat() {
+				if seen[value.i] {
+					panic(a.newError(exp, fmt.Sprintf("duplicate case %d in switch", value.i)))
+				}
+				seen[value.i] = true
+			}
+
+			newClause.Expressions[j] = newExp
+		}
+
+		// Fallthrough must be the last statement of a clause that isnt the last
+		// clause, a switch nested in the clause has its own clauses
+		enclosing := a.fallthroughSmt
+		a.fallthroughSmt = nil
+		if clause.Fallthrough() {
+			smt := clause.Body.Statements[len(clause.Body.Statements)-1]
+			if i == len(node.Clauses)-1 {
+				panic(a.newError(smt, "cannot fallthrough final case in switch"))
+			}
+			a.fallthroughSmt = smt.(*ast.FallthroughStatement)
+		}
+
+		newClause.Body = a.blockSmt(clause.Body).(*ast.BlockStatement)
+		newSwitchSmt.Clauses[i] = newClause
+		a.fallthroughSmt = enclosing
+	}
+
+	return newSwitchSmt
+}
+
+// binaryOperandType returns the type the operands of a binary expression are
+// converted to before the operator is applied
+func (a *Analysis) binaryOperandType(node *ast.BinaryExpression) types.Type {
+	lType := a.typ(node.Left)
+	rType := a.typ(node.Right)
+	if _, ok := lType.(*types.Named); ok {
+		return lType
+	}
+	if _, ok := rType.(*types.Named); ok {
+		return rType
+	}
+	if lType == floatType || rType == floatType {
+		return floatType
+	}
+	return a.operandType(node, lType, rType)
+}
+
+// comparison returns true if the operator compares its operands and produces a bool
+func comparison(operator lexer.TokenType) bool {
+	switch operator {
+	case lexer.EQL, lexer.NEQ, lexer.LSS, lexer.LEQ, lexer.GTR, lexer.GEQ:
+		return true
+	}
+
+	return false
+}
+
+// operandType returns the type arithmetic on the operands is performed in.
+// Operations keep the width of their operands so they wrap at that width,
+// constants take the type of the other operand.
+func (a *Analysis) operandType(node *ast.BinaryExpression, lType, rType types.Type) types.Type {
+	lBasic, lOk := lType.(*types.Basic)
+	rBasic, rOk := rType.(*types.Basic)
+	if !lOk || !rOk {
+		return intType
+	}
+
+	_, lConst := a.evaluate(node.Left)
+	_, rConst := a.evaluate(node.Right)
+	switch {
+	case lBasic.Type() == rBasic.Type():
+		return lType
+	case rConst && !lConst:
+		return lType
+	case lConst && !rConst:
+		return rType
+	}
+
+	return intType
+}
+
 func (a *Analysis) assigmentSmt(node *ast.AssignmentStatement) ast.Statement {
 	newAssigmentSmt := &ast.AssignmentStatement{}
 
+	if a.isConstant(node.Left) {
+		panic(a.newError(node, "cannot assign to a constant"))
+	}
+
+	if a.pure() {
+		a.pureAssignment(node, node.Left)
+	}
+
 	newAssigmentSmt.Left = a.expression(node.Left)
 	newAssigmentSmt.Right = a.expression(node.Right)
 
+	// Destructuring assignment
+	if _, ok := node.Left.(*ast.ParenLiteralExpression); ok {
+		newAssigmentSmt.Right = a.convert(newAssigmentSmt.Right, a.typ(newAssigmentSmt.Left))
+		return newAssigmentSmt
+	}
+
 	// Get type of assigment expression
-	leftType := a.typ(node.Left)
-	rightType := a.typ(node.Right)
+	leftType := a.typ(newAssigmentSmt.Left)
+	rightType := a.typ(newAssigmentSmt.Right)
 
 	// Expression doesnt match assigment type
 	// TODO: do we need llvm types of can we check base types
-	if leftType.Llvm() != rightType.Llvm() {
-		// Cast it
-		newAssigmentSmt.Right = ast.Expression(&ast.CastExpression{
-			Expression: node.Right,
-			Type:       leftType,
-		})
+	if !sameType(leftType, rightType) {
+		newAssigmentSmt.Right = a.convert(newAssigmentSmt.Right, leftType)
 	}
 
 	return newAssigmentSmt
 }
 
 func (a *Analysis) callExp(node *ast.CallExpression) ast.Expression {
+	// Variants with fields are created like a call
+	if selector, ok := node.Function.(*ast.SelectorExpression); ok {
+		if a.unionType(selector.Expression) != nil {
+			return a.variantExp(selector, node.Arguments)
+		}
+		return a.methodCallExp(selector, node.Arguments)
+	}
+
+	if a.pure() {
+		a.pureCall(node)
+	}
+
+	if name, ok := a.builtin(node); ok {
+		return a.builtinCallExp(name, node)
+	}
 
-	switch nodeType := a.typ(node.Function).(type) {
+	if f, ok := a.generic(node.Function); ok {
+		return a.genericCallExp(node, f)
+	}
+
+	// Function values, lambdas and compositions are called like any other
+	// function
+	function := a.expression(node.Function)
+
+	switch nodeType := a.typ(function).(type) {
 	// Regular function call
 	case *types.Function:
 		newCallExp := &ast.CallExpression{}
-		newCallExp.Function = node.Function
+		newCallExp.Function = function
+		newCallExp.Type = nodeType
 
 		// Cast arguments
 		newCallExp.Arguments = &ast.ParenLiteralExpression{
@@ -331,7 +1204,8 @@ func (a *Analysis) callExp(node *ast.CallExpression) ast.Expression {
 			// Auto cast arguments
 			defType := nodeType.Arguments()[i] // definition type
 			typ := a.typ(newArg)               // actual type
-			if typ.Llvm() != defType.Llvm() {
+			if !sameType(typ, defType) {
+				a.castable(arg, typ, defType)
 				log.Printf("Casting argument %d", i)
 				newCallExp.Arguments.Elements[i] = &ast.CastExpression{
 					Expression: newArg,
@@ -349,6 +1223,31 @@ func (a *Analysis) callExp(node *ast.CallExpression) ast.Expression {
 		// TODO: check for multiple arguments
 		newCastExp.Expression = a.expression(node.Arguments.Elements[0])
 		newCastExp.Type = nodeType
+		if !convertible(a.typ(newCastExp.Expression), nodeType) {
+			panic(a.newError(node, fmt.Sprintf("cannot convert %v to %v",
+				a.typ(newCastExp.Expression), nodeType)))
+		}
+		if a.pure() && textual(nodeType) && !textual(a.typ(newCastExp.Expression)) {
+			a.impure(node, "converts a rune to a string")
+		}
+
+		return newCastExp
+
+	// Conversion to or from a distinct named type
+	case *types.Named:
+		newCastExp := &ast.CastExpression{
+			Expression: a.expression(node.Arguments.Elements[0]),
+			Type:       nodeType,
+		}
+
+		from := types.Underlying(a.typ(newCastExp.Expression))
+		if sameType(from, types.Underlying(nodeType)) {
+			return newCastExp
+		}
+		if neverCast(from) || neverCast(types.Underlying(nodeType)) || !convertible(from, nodeType) {
+			panic(a.newError(node, fmt.Sprintf("cannot convert %v to %v",
+				a.typ(newCastExp.Expression), nodeType)))
+		}
 
 		return newCastExp
 	default:
@@ -359,29 +1258,76 @@ func (a *Analysis) callExp(node *ast.CallExpression) ast.Expression {
 	return node // Unreachable
 }
 
+// compareArrays checks the operands of an array comparison have the same type
+// and their elements can be compared
+func (a *Analysis) compareArrays(node *ast.BinaryExpression) {
+	left, right := a.typ(node.Left), a.typ(node.Right)
+	switch operator := node.Operator.Type(); {
+	case operator != lexer.EQL && operator != lexer.NEQ, !comparable(left):
+		panic(a.newError(node, fmt.Sprintf("operator %s not defined on %s", operator, left)))
+	case !sameType(left, right):
+		panic(a.newError(node, fmt.Sprintf("mismatched types %s and %s", left, right)))
+	}
+}
+
+// comparable returns true if values of the type can be compared with ==
+func comparable(typ types.Type) bool {
+	switch typ := types.Underlying(typ).(type) {
+	case *types.Basic:
+		return !textual(typ)
+	case *types.Array:
+		return comparable(typ.Base())
+	}
+
+	return false
+}
+
 func (a *Analysis) binaryExp(node *ast.BinaryExpression) ast.Expression {
 	log.Printf("Binary %s node", node.Operator.String())
 
+	switch {
+	case node.Operator.Type() == lexer.PIPE:
+		return a.pipelineExp(node)
+	case a.isComposition(node):
+		return a.compositionExp(node)
+	}
+
 	newBinaryExp := &ast.BinaryExpression{
 		Left:     a.expression(node.Left),
 		Operator: node.Operator,
 		Right:    a.expression(node.Right),
 	}
 
-	// Gets the overall type of node
-	typ := a.typ(newBinaryExp)
-	newBinaryExp.IsFp = typ.(*types.Basic).Info()&types.IsFloat != 0
+	// Arrays are compared element by element
+	if _, ok := types.Underlying(a.typ(newBinaryExp.Left)).(*types.Array); ok {
+		a.compareArrays(newBinaryExp)
+		return newBinaryExp
+	}
+
+	// Gets the type the operands are compared or combined in
+	typ := a.binaryOperandType(newBinaryExp)
+	basic, ok := types.Underlying(typ).(*types.Basic)
+	if !ok {
+		panic(a.newError(node, fmt.Sprintf("operator %s not defined on %s", node.Operator.Type(), typ)))
+	}
+	if textual(typ) || boolean(typ) && node.Operator.Type() != lexer.EQL && node.Operator.Type() != lexer.NEQ {
+		panic(a.newError(node, fmt.Sprintf("operator %s not defined on %s", node.Operator.Type(), typ)))
+	}
+	newBinaryExp.IsFp = basic.Info()&types.IsFloat != 0
 
-	// If left part of the node doesnt match the type of the node cast it
+	// If left part of the node doesnt match the type of the operands cast it,
+	// operands of a distinct named type can only be mixed with constants
 	if leftTyp := a.typ(newBinaryExp.Left); leftTyp != typ {
+		a.castable(newBinaryExp.Left, leftTyp, typ)
 		newBinaryExp.Left = &ast.CastExpression{
 			Expression: newBinaryExp.Left,
 			Type:       typ,
 		}
 	}
 
-	// If the right part of the node doesnt match the type of the node cast it
+	// If the right part of the node doesnt match the type of the operands cast it
 	if rightTyp := a.typ(newBinaryExp.Right); rightTyp != typ {
+		a.castable(newBinaryExp.Right, rightTyp, typ)
 		newBinaryExp.Right = &ast.CastExpression{
 			Expression: newBinaryExp.Right,
 			Type:       typ,
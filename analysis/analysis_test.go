@@ -18,6 +18,31 @@ func init() {
 
 var a = &Analysis{}
 
+// expectError analyses the source and checks it fails with the error message
+func expectError(t *testing.T, src, message string) {
+	t.Helper()
+
+	tokens, err := lexer.NewLexer([]byte(src)).Lex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := parser.NewParser(tokens, true).Parse()
+
+	defer func() {
+		e, ok := recover().(*Error)
+		if !ok {
+			t.Errorf("Expected analysis error %q", message)
+			return
+		}
+		if e.Error() != message {
+			t.Errorf("Expected analysis error %q, got %q", message, e.Error())
+		}
+	}()
+
+	NewAnalysis(tree).Analalize()
+}
+
 func TestAnalysisExpressions(t *testing.T) {
 	cases := []struct {
 		preAnalisis  ast.Expression
@@ -319,3 +344,2090 @@ func TestCast(t *testing.T) {
 		}
 	}
 }
+
+func TestSwitchErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"duplicate case",
+			`proc main :: i32 x -> i32 {
+				switch x {
+				case 1, 2:
+					return 1
+				case 2:
+					return 2
+				}
+				return 0
+			}`,
+			"3:10: duplicate case 2 in switch",
+		},
+		{
+			"case overflows tag",
+			`proc main :: i8 x -> i32 {
+				switch x {
+				case 0:
+					return 1
+				case 256:
+					return 2
+				}
+				return 0
+			}`,
+			"3:10: constant 256 overflows i8",
+		},
+		{
+			"duplicate case after conversion",
+			`proc main :: int x -> i32 {
+				switch x {
+				case 1:
+					return 1
+				case 1.0:
+					return 2
+				}
+				return 0
+			}`,
+			"3:10: duplicate case 1 in switch",
+		},
+		{
+			"slice tag",
+			`proc main :: -> i32 {
+				s := make(i32[], 3)
+				switch s {
+				case s:
+					return 1
+				}
+				return 0
+			}`,
+			"2:12: cannot switch on i32[]",
+		},
+		{
+			"non-bool case in tagless switch",
+			`proc main :: -> i32 {
+				switch {
+				case 1:
+					return 1
+				}
+				return 0
+			}`,
+			"1:45: non-bool condition of type int",
+		},
+		{
+			"string tag",
+			`proc main :: -> i32 {
+				s := "abc"
+				switch s {
+				case "abc":
+					return 1
+				}
+				return 0
+			}`,
+			"2:12: cannot switch on string",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestFallthroughErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"fallthrough before the end of a clause",
+			`proc main :: i32 x -> i32 {
+				switch x {
+				case 1:
+					fallthrough
+					x = 2
+				case 2:
+					return 2
+				}
+				return 0
+			}`,
+			"2:6: fallthrough statement out of place",
+		},
+		{
+			"fallthrough in final clause",
+			`proc main :: i32 x -> i32 {
+				switch x {
+				case 1:
+					return 1
+				default:
+					fallthrough
+				}
+				return 0
+			}`,
+			"3:19: cannot fallthrough final case in switch",
+		},
+		{
+			"fallthrough outside switch",
+			`proc main :: i32 x -> i32 {
+				fallthrough
+				return 0
+			}`,
+			"1:33: fallthrough statement out of place",
+		},
+		{
+			"fallthrough nested in if",
+			`proc main :: i32 x -> i32 {
+				switch x {
+				case 1:
+					if x > 0 {
+						fallthrough
+					}
+				case 2:
+					return 2
+				}
+				return 0
+			}`,
+			"2:23: fallthrough statement out of place",
+		},
+		{
+			"fallthrough ending a nested switch",
+			`proc main :: i32 x -> i32 {
+				switch x {
+				case 1:
+					switch x {
+					case 1:
+						fallthrough
+					}
+				case 2:
+					return 2
+				}
+				return 0
+			}`,
+			"3:7: cannot fallthrough final case in switch",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestReturnErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"value returned from void proc",
+			`proc main :: -> {
+				return 123
+			}`,
+			"1:23: unexpected return value in proc \"main\" with no return type",
+		},
+		{
+			"bare return from non-void proc",
+			`proc main :: -> i32 {
+				return
+			}`,
+			"1:27: missing return value in proc \"main\"",
+		},
+		{
+			"non-void proc falls off the end",
+			`proc main :: i32 x -> i32 {
+				if x > 2 {
+					return 1
+				}
+			}`,
+			"1:6: missing return at end of proc \"main\"",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestAllocationErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"free of non-pointer",
+			`proc main :: -> i32 {
+				a := 123
+				free(a)
+				return 0
+			}`,
+			"2:5: cannot free type int",
+		},
+		{
+			"make of non-slice",
+			`proc main :: -> i32 {
+				a := make(i32, 3)
+				return 0
+			}`,
+			"1:32: cannot make type i32",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestForwardReference(t *testing.T) {
+	code := `
+		proc main :: -> i32 {
+			return add(10, 243)
+		}
+
+		proc add :: i32 a, i64 b -> i64 {
+			return a + b
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+
+	returnSmt := tree.Functions[0].Body.Statements[0].(*ast.ReturnStatement)
+	cast, ok := returnSmt.Result.(*ast.CastExpression)
+	if !ok {
+		t.Fatalf("Expected return value to be of type \"*ast.CastExpression\", got %q",
+			reflect.TypeOf(returnSmt.Result).String())
+	}
+
+	call := cast.Expression.(*ast.CallExpression)
+	if _, ok := call.Arguments.Elements[0].(*ast.CastExpression); !ok {
+		t.Errorf("Expected parameter 0 to be a cast got %s",
+			pp.Sprint(call.Arguments.Elements[0]))
+	}
+}
+
+func TestRedeclaredProc(t *testing.T) {
+	code := `
+		proc main :: -> i32 {
+			return 1
+		}
+
+		proc main :: -> i32 {
+			return 2
+		}
+	`
+
+	expectError(t, code, "3:9: proc \"main\" redeclared")
+}
+
+func TestTupleReturn(t *testing.T) {
+	code := `
+		proc divmod :: i64 a, i64 b -> (i32, i32) {
+			return a / b, a % b
+		}
+
+		proc main :: -> i32 {
+			q, r := divmod(7, 2)
+			return q + r
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+
+	// Each element of the returned tuple is cast to the return type
+	returnSmt := tree.Functions[0].Body.Statements[0].(*ast.ReturnStatement)
+	paren, ok := returnSmt.Result.(*ast.ParenLiteralExpression)
+	if !ok {
+		t.Fatalf("Expected return value to be of type \"*ast.ParenLiteralExpression\", got %q",
+			reflect.TypeOf(returnSmt.Result).String())
+	}
+
+	for i, element := range paren.Elements {
+		if _, ok := element.(*ast.CastExpression); !ok {
+			t.Errorf("Expected element %d to be a cast got %s", i, pp.Sprint(element))
+		}
+	}
+
+	// Destructured varibles take the type of each element
+	dclSmt := tree.Functions[1].Body.Statements[0].(*ast.DeclareStatement)
+	tupleDcl := dclSmt.Statement.(*ast.TupleDeclaration)
+	for _, dcl := range tupleDcl.Declarations {
+		if !reflect.DeepEqual(dcl.Type, types.IntType(32)) {
+			t.Errorf("Expected %q to have type \"i32\", got %q",
+				dcl.Name.Value.Value(), dcl.Type.String())
+		}
+	}
+}
+
+func TestPointerErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"address of literal",
+			`proc main :: -> i32 {
+				p := &123
+				return 0
+			}`,
+			"1:32: cannot take the address of a non-addressable expression",
+		},
+		{
+			"address of proc",
+			`proc main :: -> i32 {
+				p := &main
+				return 0
+			}`,
+			"1:32: cannot take the address of a non-addressable expression",
+		},
+		{
+			"dereference of non-pointer",
+			`proc main :: -> i32 {
+				a := 123
+				return *a
+			}`,
+			"2:12: cannot dereference non-pointer type int",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestDeferErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"defer of new",
+			`proc main :: -> i32 {
+				defer new(i32)
+				return 0
+			}`,
+			"1:27: defer discards result of new",
+		},
+		{
+			"defer in loop",
+			`proc main :: -> i32 {
+				p := new(i32)
+				for i := 0; i < 3; i++ {
+					defer free(p)
+				}
+				return 0
+			}`,
+			"2:35: defer inside a for loop is not supported",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestConstantIndexOutOfBounds(t *testing.T) {
+	code := `proc main :: -> i32 {
+		items := i32[3]{1, 2, 3}
+		return items[3]
+	}`
+
+	expectError(t, code, "2:16: index 3 out of bounds for i32[3]")
+}
+
+func TestOperandWidth(t *testing.T) {
+	cases := []struct {
+		code string
+		typ  types.Type
+	}{
+		{"i8 a = 1\nb := a + 1", types.IntType(8)},
+		{"i16 a = 1\nb := 1 * a", types.IntType(16)},
+		{"i32 a = 1\ni32 c = 2\nb := a - c", types.IntType(32)},
+		{"i32 a = 1\ni64 c = 2\nb := a - c", types.IntType(0)},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.NewLexer([]byte("proc main :: -> i32 {\n" + c.code + "\nreturn 0\n}")).Lex()
+		if err != nil {
+			t.Error(err)
+		}
+
+		tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+		statements := tree.Functions[0].Body.Statements
+		dcl := statements[len(statements)-2].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+		if !reflect.DeepEqual(dcl.Type, c.typ) {
+			t.Errorf("%q: expected b to have type %q, got %q", c.code, c.typ, dcl.Type)
+		}
+	}
+}
+
+func TestIntegerConstantOverflow(t *testing.T) {
+	code := `proc main :: -> i32 {
+		a := 9223372036854775808
+		return 0
+	}`
+
+	expectError(t, code, "1:30: integer constant 9223372036854775808 overflows int")
+}
+
+func TestConstantFolding(t *testing.T) {
+	cases := []struct {
+		code  string
+		value ast.Expression
+	}{
+		{
+			"a := 33 + ((20 + 5) * 4) - 10",
+			&ast.LiteralExpression{
+				Value: lexer.NewToken(lexer.INT, "123", 1, 28),
+			},
+		},
+		{
+			"a := 1.5 * 2",
+			&ast.LiteralExpression{
+				Value: lexer.NewToken(lexer.FLOAT, "3", 1, 28),
+			},
+		},
+		{
+			"i8 a = 100 + 100",
+			&ast.CastExpression{
+				Type: types.IntType(8),
+				Expression: &ast.LiteralExpression{
+					Value: lexer.NewToken(lexer.INT, "-56", 1, 30),
+				},
+			},
+		},
+		{
+			"const b = 6\na := b * 7",
+			&ast.LiteralExpression{
+				Value: lexer.NewToken(lexer.INT, "42", 1, 33),
+			},
+		},
+		{
+			"a := 0xFF + 0o17 + 0b1010 + 1_000",
+			&ast.LiteralExpression{
+				Value: lexer.NewToken(lexer.INT, "1280", 1, 28),
+			},
+		},
+		{
+			"a := 0x1p-2 + 1e3",
+			&ast.LiteralExpression{
+				Value: lexer.NewToken(lexer.FLOAT, "1000.25", 1, 28),
+			},
+		},
+		{
+			"i64 a = 0x7FFF_FFFF_FFFF_FFFF",
+			&ast.CastExpression{
+				Type: types.IntType(64),
+				Expression: &ast.LiteralExpression{
+					Value: lexer.NewToken(lexer.INT, "9223372036854775807", 1, 31),
+				},
+			},
+		},
+		{
+			"i64 a = -9223372036854775808",
+			&ast.CastExpression{
+				Type: types.IntType(64),
+				Expression: &ast.LiteralExpression{
+					Value: lexer.NewToken(lexer.INT, "-9223372036854775808", 1, 31),
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.NewLexer([]byte("proc main :: -> i32 {\n" + c.code + "\nreturn 0\n}")).Lex()
+		if err != nil {
+			t.Error(err)
+		}
+
+		tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+		statements := tree.Functions[0].Body.Statements
+		dcl := statements[len(statements)-2].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+		if !reflect.DeepEqual(dcl.Value, c.value) {
+			t.Errorf("%q: expected:\n%s\ngot:\n%s", c.code, pp.Sprint(c.value), pp.Sprint(dcl.Value))
+		}
+	}
+}
+
+func TestConstantErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"constant division by zero",
+			`proc main :: -> i32 {
+				a := 10
+				return a / (5 - 5)
+			}`,
+			"2:12: division by zero",
+		},
+		{
+			"index division by zero",
+			`proc main :: -> i32 {
+				items := i32[3]{1, 2, 3}
+				return items[3 / 0]
+			}`,
+			"2:18: division by zero",
+		},
+		{
+			"array length division by zero",
+			`const n = 0
+			proc main :: -> i32 {
+				a := i32[4 / n]{}
+				return 0
+			}`,
+			"2:39: division by zero",
+		},
+		{
+			"named constant division by zero",
+			`type Celsius f64
+			proc main :: -> i32 {
+				Celsius c = 5 / 0
+				return 0
+			}`,
+			"2:42: division by zero",
+		},
+		{
+			"non-constant const value",
+			`proc main :: -> i32 {
+				a := 10
+				const b = a + 1
+				return b
+			}`,
+			"2:5: value of const \"b\" is not a constant",
+		},
+		{
+			"integer literal overflow",
+			`proc main :: -> i32 {
+				a := 0x1_0000_0000_0000_0000
+				return 0
+			}`,
+			"1:32: integer constant 0x1_0000_0000_0000_0000 overflows int",
+		},
+		{
+			"float literal overflow",
+			`proc main :: -> i32 {
+				a := 1e400
+				return 0
+			}`,
+			"1:32: floating-point constant 1e400 overflows float",
+		},
+		{
+			"assignment to const",
+			`proc main :: -> i32 {
+				const b = 1
+				b = 2
+				return b
+			}`,
+			"2:5: cannot assign to a constant",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	code := `
+		i8 small = 100 + 27
+		count := next()
+
+		proc next :: -> i32 {
+			return 1
+		}
+
+		proc main :: -> i32 {
+			count = count + small
+			return count
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+
+	small := tree.Globals[0].(*ast.VaribleDeclaration)
+	if _, ok := small.Value.(*ast.CastExpression).Expression.(*ast.LiteralExpression); !ok {
+		t.Errorf("Expected constant global value to be folded, got %s", pp.Sprint(small.Value))
+	}
+
+	count := tree.Globals[1].(*ast.VaribleDeclaration)
+	if !reflect.DeepEqual(count.Type, types.IntType(32)) {
+		t.Errorf("Expected count to have type \"i32\", got %q", count.Type)
+	}
+}
+
+func TestRedeclaredGlobal(t *testing.T) {
+	code := `
+		main := 1
+
+		proc main :: -> i32 {
+			return 0
+		}
+	`
+
+	expectError(t, code, "1:4: \"main\" redeclared")
+}
+
+func TestPureErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"global assignment",
+			`count := 0
+			func bump :: i32 a -> i32 {
+				count = a
+				return a
+			}`,
+			"2:36: func \"bump\" assigns to global \"count\"",
+		},
+		{
+			"impure call",
+			`proc impure :: i32 a -> i32 {
+				return a
+			}
+			func pure :: i32 a -> i32 {
+				return impure(a)
+			}`,
+			"3:43: func \"pure\" calls impure proc \"impure\"",
+		},
+		{
+			"pointer write",
+			`func store :: *i32 p -> i32 {
+				p[0] = 1
+				return 0
+			}`,
+			"1:35: func \"store\" writes through a pointer",
+		},
+		{
+			"builtin call",
+			`func release :: *i32 p -> i32 {
+				free(p)
+				return 0
+			}`,
+			"1:37: func \"release\" calls builtin \"free\"",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestPureMemory(t *testing.T) {
+	code := `
+		scale := 2
+
+		func square :: i32 a -> i32 {
+			b := a * a
+			return b
+		}
+
+		func scaled :: i32 a -> i32 {
+			return square(a) * scale
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+
+	if square := tree.Functions[0]; !square.Pure || square.ReadOnly {
+		t.Errorf("Expected square to be pure and read no memory")
+	}
+
+	if scaled := tree.Functions[1]; !scaled.Pure || !scaled.ReadOnly {
+		t.Errorf("Expected scaled to be pure and read memory")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	code := `
+		proc double :: i32 a -> i32 {
+			return a + a
+		}
+
+		proc main :: -> i32 {
+			return 3 |> double >> double
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	result := tree.Functions[1].Body.Statements[0].(*ast.ReturnStatement).Result
+
+	call, ok := result.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("Expected pipeline to become a call, got %s", pp.Sprint(result))
+	}
+
+	composition, ok := call.Function.(*ast.CompositionExpression)
+	if !ok {
+		t.Fatalf("Expected composition to be called, got %s", pp.Sprint(call.Function))
+	}
+
+	expected := types.NewFunction(types.IntType(32), types.IntType(32))
+	if !reflect.DeepEqual(composition.Type, expected) {
+		t.Errorf("Expected composition type %s, got %s", expected, composition.Type)
+	}
+}
+
+func TestCompositionErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"mismatched composition",
+			`proc widen :: i32 a -> i64 {
+				return a
+			}
+			proc main :: -> i32 {
+				return (widen >> widen)(1)
+			}`,
+			"3:38: cannot compose (i32) i64 with (i32) i64",
+		},
+		{
+			"mismatched pipeline stage",
+			`proc widen :: i32 a -> i64 {
+				return a
+			}
+			proc main :: -> i32 {
+				return 1 |> widen |> widen
+			}`,
+			"3:51: pipeline stage expects i32, got i64",
+		},
+		{
+			"pipe into non-function",
+			`proc main :: -> i32 {
+				a := 1
+				return 1 |> a
+			}`,
+			"2:17: cannot pipe into a non-function",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestLambdaCaptures(t *testing.T) {
+	code := `
+		proc main :: -> i32 {
+			offset := 1
+			nested := proc :: i32 a -> i32 {
+				inner := proc :: -> i32 {
+					return offset + a
+				}
+				return inner()
+			}
+			return nested(2)
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	statements := tree.Functions[0].Body.Statements
+	nested := statements[1].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration).Value.(*ast.LambdaExpression)
+	inner := nested.Function.Body.Statements[0].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration).Value.(*ast.LambdaExpression)
+
+	captures := func(lambda *ast.LambdaExpression) []string {
+		names := []string{}
+		for _, capture := range lambda.Captures {
+			names = append(names, capture.Name.Value.Value())
+		}
+		return names
+	}
+
+	if names := captures(nested); !reflect.DeepEqual(names, []string{"offset"}) {
+		t.Errorf("Expected outer lambda to capture [offset], got %v", names)
+	}
+
+	if names := captures(inner); !reflect.DeepEqual(names, []string{"offset", "a"}) {
+		t.Errorf("Expected inner lambda to capture [offset a], got %v", names)
+	}
+}
+
+func TestFunctionValueErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"mismatched function argument",
+			`proc widen :: i32 a -> i64 {
+				return a
+			}
+			proc apply :: proc(i32 -> i32) f -> i32 {
+				return f(1)
+			}
+			proc main :: -> i32 {
+				return apply(widen)
+			}`,
+			"5:43: cannot use (i32) i64 as (i32) i32",
+		},
+		{
+			"function value cast to integer",
+			`proc one :: -> i32 {
+				return 1
+			}
+			proc main :: -> i32 {
+				i32 a = 0
+				a = one
+				return a
+			}`,
+			"4:9: cannot use () i32 as i32",
+		},
+		{
+			"pure call of function value",
+			`func apply :: proc(i32 -> i32) f -> i32 {
+				return f(1)
+			}`,
+			"1:54: func \"apply\" calls function value \"f\"",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestGenericInstances(t *testing.T) {
+	code := `
+		proc max<T: ordered> :: T a, T b -> T {
+			if a > b {
+				return a
+			}
+			return b
+		}
+		proc main :: -> i32 {
+			f64 f = 1.5
+			a := max(1, 2)
+			b := max(f, 2)
+			c := max(3, 4)
+			return a
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	instances := tree.Functions[0].Instances
+
+	typeArguments := [][]types.Type{}
+	for _, instance := range instances {
+		typeArguments = append(typeArguments, instance.TypeArguments)
+	}
+
+	expected := [][]types.Type{
+		{types.IntType(0)},
+		{types.FloatType(64)},
+	}
+	if !reflect.DeepEqual(typeArguments, expected) {
+		t.Errorf("Expected instances %v, got %v", expected, typeArguments)
+	}
+
+	if ret := instances[1].Return; !reflect.DeepEqual(ret, types.FloatType(64)) {
+		t.Errorf("Expected f64 instance to return f64, got %v", ret)
+	}
+
+	call := tree.Functions[1].Body.Statements[2].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration).Value.(*ast.CallExpression)
+	if !reflect.DeepEqual(call.TypeArguments, expected[1]) {
+		t.Errorf("Expected call with type arguments %v, got %v", expected[1], call.TypeArguments)
+	}
+}
+
+func TestGenericErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"constraint not satisfied",
+			`proc twice<T: numeric> :: T a -> T {
+				return a + a
+			}
+			proc main :: -> i32 {
+				p := new(i32)
+				q := twice(p)
+				return 0
+			}`,
+			"4:10: *i32 does not satisfy the constraint on type parameter T of proc \"twice\"",
+		},
+		{
+			"conflicting inference",
+			`proc max<T: ordered> :: T a, T b -> T {
+				return a
+			}
+			proc main :: -> i32 {
+				i64 a = 1
+				f32 b = 2.0
+				return max(a, b)
+			}`,
+			"5:19: cannot use f32 as T in call to proc \"max\"",
+		},
+		{
+			"generic proc as value",
+			`proc id<T> :: T a -> T {
+				return a
+			}
+			proc main :: -> i32 {
+				f := id
+				return 0
+			}`,
+			"3:35: cannot use generic proc \"id\" without calling it",
+		},
+		{
+			"undefined name in uninstantiated generic",
+			`proc add<T: numeric> :: T a -> T {
+				return a + undefinedName
+			}
+			proc main :: -> i32 {
+				return 0
+			}`,
+			"1:51: undefined: undefinedName",
+		},
+		{
+			"undefined name",
+			`proc main :: -> i32 {
+				return undefinedName
+			}`,
+			"1:34: undefined: undefinedName",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	code := `
+		type Shape enum { Circle(f64 r), Square(i32 side) }
+		proc area :: Shape s -> f64 {
+			return match s {
+				case Circle(r): r * r
+				case Square(side): side * side
+			}
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	match := tree.Functions[0].Body.Statements[0].(*ast.ReturnStatement).Result.(*ast.MatchExpression)
+
+	if !reflect.DeepEqual(match.Type, types.FloatType(64)) {
+		t.Errorf("Expected match of type f64, got %v", match.Type)
+	}
+
+	if tags := []int{match.Clauses[0].Tag, match.Clauses[1].Tag}; !reflect.DeepEqual(tags, []int{0, 1}) {
+		t.Errorf("Expected clause tags [0 1], got %v", tags)
+	}
+
+	if _, ok := match.Clauses[1].Result.(*ast.CastExpression); !ok {
+		t.Errorf("Expected i32 result to be cast to f64, got %s", pp.Sprint(match.Clauses[1].Result))
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	shape := `type Shape enum { Circle(f64 r), Rect(f64 w, f64 h), Empty }
+	`
+
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"missing variant",
+			shape + `proc area :: Shape s -> f64 {
+				match s {
+				case Circle(r):
+					return r
+				case Empty:
+					return 0
+				}
+				return 1
+			}`,
+			"2:36: match on Shape does not handle Rect",
+		},
+		{
+			"duplicate case",
+			shape + `proc area :: Shape s -> f64 {
+				return match s {
+					case Circle(r): r
+					case Circle: 1
+					default: 0
+				}
+			}`,
+			"3:6: duplicate case Circle in match",
+		},
+		{
+			"unknown variant",
+			shape + `proc main :: -> i32 {
+				s := Shape.Square(1.0)
+				return 0
+			}`,
+			"2:33: Shape has no variant \"Square\"",
+		},
+		{
+			"wrong number of fields",
+			shape + `proc main :: -> i32 {
+				s := Shape.Rect(1.0)
+				return 0
+			}`,
+			"2:33: variant Shape.Rect has 2 fields, got 1 values",
+		},
+		{
+			"partial bindings",
+			shape + `proc area :: Shape s -> f64 {
+				return match s {
+					case Rect(w): w
+					default: 0
+				}
+			}`,
+			"2:58: case Rect binds 1 of 2 fields",
+		},
+		{
+			"match on non union",
+			`proc main :: -> i32 {
+				a := 1
+				match a {
+				default:
+					return 0
+				}
+			}`,
+			"2:11: cannot match on int, only unions can be matched",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestMethods(t *testing.T) {
+	code := `
+		type Shape enum { Circle(f64 r), Empty }
+		type Measured interface { area :: -> f64 }
+		proc (Shape s) area :: -> f64 {
+			return 2.0
+		}
+		proc main :: -> i32 {
+			s := Shape.Empty
+			Measured m = s
+			static := s.area()
+			dynamic := m.area()
+			return 0
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+
+	shape := tree.Types[0].Value
+	measured := tree.Types[1].Value
+	expected := []*ast.Implementation{{Type: shape, Interface: measured.(*types.Interface)}}
+	if !reflect.DeepEqual(tree.Implementations, expected) {
+		t.Errorf("Expected Shape to implement Measured, got %s", pp.Sprint(tree.Implementations))
+	}
+
+	m := tree.Functions[1].Body.Statements[1].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if _, ok := m.Value.(*ast.InterfaceExpression); !ok {
+		t.Errorf("Expected Shape to be converted to Measured, got %s", pp.Sprint(m.Value))
+	}
+
+	receivers := []types.Type{shape, measured}
+	for i, receiver := range receivers {
+		dcl := tree.Functions[1].Body.Statements[i+2].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+		if call, ok := dcl.Value.(*ast.MethodCallExpression); !ok || call.Type != receiver {
+			t.Errorf("Expected method call on %s, got %s", receiver, pp.Sprint(dcl.Value))
+		}
+	}
+}
+
+func TestInterfaceErrors(t *testing.T) {
+	shape := `type Shape enum { Circle(f64 r), Empty }
+	type Measured interface { area :: -> f64 }
+	`
+
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"missing method",
+			shape + `proc main :: -> i32 {
+				Measured m = Shape.Empty
+				return 0
+			}`,
+			"3:41: Shape does not implement Measured (missing method area)",
+		},
+		{
+			"unknown method",
+			shape + `proc main :: -> i32 {
+				s := Shape.Empty
+				return i32(s.area())
+			}`,
+			"4:16: Shape has no method \"area\"",
+		},
+		{
+			"method on interface",
+			shape + `proc (Measured m) area :: -> f64 {
+				return 0
+			}`,
+			"3:20: cannot declare method \"area\" on Measured",
+		},
+		{
+			"redeclared method",
+			shape + `proc (Shape s) area :: -> f64 {
+				return 0
+			}
+			proc (Shape s) area :: -> f64 {
+				return 1
+			}`,
+			"5:19: method Shape.area redeclared",
+		},
+		{
+			"wrong signature",
+			shape + `proc (Shape s) area :: -> i32 {
+				return 0
+			}
+			proc main :: -> i32 {
+				Measured m = Shape.Empty
+				return 0
+			}`,
+			"5:43: Shape does not implement Measured (missing method area)",
+		},
+		{
+			"method on basic type",
+			`proc (i32 a) double :: -> i32 {
+				return a * 2
+			}`,
+			"1:14: cannot declare method \"double\" on i32",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestStructs(t *testing.T) {
+	code := `
+		type Vec struct { f64 x, f64 y }
+		proc (Vec v) length :: -> f64 {
+			return v.x * v.x + v.y * v.y
+		}
+		proc main :: -> i32 {
+			v := Vec{3, 4.0}
+			v.y = 1
+			return i32(v.length())
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	vec := tree.Types[0].Value.(*types.Struct)
+
+	if method, _ := vec.Method("length"); method == nil {
+		t.Errorf("Expected Vec to have method length")
+	}
+
+	statements := tree.Functions[1].Body.Statements
+	literal := statements[0].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration).Value.(*ast.BraceLiteralExpression)
+	if _, ok := literal.Elements[0].(*ast.CastExpression); !ok {
+		t.Errorf("Expected integer field value to be cast to f64, got %s", pp.Sprint(literal.Elements[0]))
+	}
+
+	field, ok := statements[1].(*ast.AssignmentStatement).Left.(*ast.FieldExpression)
+	if !ok || field.Struct != vec || field.Index != 1 {
+		t.Errorf("Expected assignment to field y of Vec, got %s", pp.Sprint(statements[1]))
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	vec := `type Vec struct { f64 x, f64 y }
+	proc (Vec v) length :: -> f64 {
+		return v.x
+	}
+	`
+
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"unknown field",
+			vec + `proc main :: -> i32 {
+				v := Vec{1.0, 2.0}
+				return i32(v.z)
+			}`,
+			"5:16: Vec has no field or method \"z\"",
+		},
+		{
+			"method not called",
+			vec + `proc main :: -> i32 {
+				v := Vec{1.0, 2.0}
+				l := v.length
+				return 0
+			}`,
+			"5:10: method Vec.length must be called",
+		},
+		{
+			"wrong number of values",
+			vec + `proc main :: -> i32 {
+				v := Vec{1.0}
+				return 0
+			}`,
+			"4:36: Vec has 2 fields, got 1 values",
+		},
+		{
+			"field of non struct",
+			`proc main :: -> i32 {
+				a := 1
+				return a.x
+			}`,
+			"2:12: int has no field or method \"x\"",
+		},
+		{
+			"assign global field in pure func",
+			vec + `origin := Vec{0.0, 0.0}
+			func reset :: -> f64 {
+				origin.x = 1.0
+				return 0
+			}`,
+			"5:31: func \"reset\" assigns to global \"origin\"",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestNamedTypes(t *testing.T) {
+	code := `
+		proc main :: -> i32 {
+			Celsius c = 20
+			f64 raw = 1.5
+			warm := c + Celsius(raw)
+			Id id = 4
+			return i32(warm) + id
+		}
+		type Celsius f64
+		type Id = i32
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	celsius := tree.Types[0].Value
+
+	statements := tree.Functions[0].Body.Statements
+	c := statements[0].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if cast, ok := c.Value.(*ast.CastExpression); !ok || cast.Type != celsius {
+		t.Errorf("Expected constant to be converted to Celsius, got %s", pp.Sprint(c.Value))
+	}
+
+	warm := statements[2].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if warm.Type != celsius {
+		t.Errorf("Expected sum of Celsius values to be Celsius, got %s", warm.Type)
+	}
+
+	id := statements[3].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if !reflect.DeepEqual(id.Type, types.IntType(32)) {
+		t.Errorf("Expected alias to be i32, got %s", id.Type)
+	}
+}
+
+func TestNamedTypeErrors(t *testing.T) {
+	celsius := `type Celsius f64
+	`
+
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"mixed operands",
+			celsius + `proc main :: -> i32 {
+				Celsius c = 20
+				f64 raw = 1.5
+				warm := c + raw
+				return 0
+			}`,
+			"4:17: cannot use f64 as Celsius without a conversion",
+		},
+		{
+			"assign to underlying type",
+			celsius + `proc main :: -> i32 {
+				Celsius c = 20
+				f64 raw = c
+				return 0
+			}`,
+			"3:15: cannot use Celsius as f64 without a conversion",
+		},
+		{
+			"argument of underlying type",
+			celsius + `proc warm :: Celsius c -> Celsius {
+				return c + 1
+			}
+			proc main :: -> i32 {
+				f64 raw = 1.5
+				warm(raw)
+				return 0
+			}`,
+			"5:10: cannot use f64 as Celsius without a conversion",
+		},
+		{
+			"operator on named struct",
+			`type Vec struct { f64 x }
+			type Point Vec
+			proc main :: -> i32 {
+				Point p = Point(Vec{1.0})
+				q := p + p
+				return 0
+			}`,
+			"4:10: operator + not defined on Point",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestArrayLengths(t *testing.T) {
+	code := `
+		const N = 2
+		const M = N * 3
+		proc main :: -> i32 {
+			i32[M - 1][N] m = i32[5][2]{{1, 2}}
+			return m[4][1]
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+
+	m := tree.Functions[0].Body.Statements[0].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	expected := types.NewArray(types.NewArray(types.IntType(32), 2), 5)
+	if !reflect.DeepEqual(m.Type, expected) {
+		t.Errorf("Expected %s, got %s", expected, m.Type)
+	}
+}
+
+func TestArrayLengthErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"non constant length",
+			`n := 3
+			proc main :: -> i32 {
+				i32[n] a = i32[3]{1, 2, 3}
+				return 0
+			}`,
+			"2:34: array length must be a non-negative integer constant",
+		},
+		{
+			"negative length",
+			`const N = 0 - 2
+			proc main :: -> i32 {
+				i32[N] a = i32[0]{}
+				return 0
+			}`,
+			"2:34: array length must be a non-negative integer constant",
+		},
+		{
+			"float length",
+			`proc main :: -> i32 {
+				i32[1.5] a = i32[1]{1}
+				return 0
+			}`,
+			"1:31: array length must be a non-negative integer constant",
+		},
+		{
+			"too many values",
+			`proc main :: -> i32 {
+				m := i32[2][2]{{1, 2}, {3, 4}, {5, 6}}
+				return 0
+			}`,
+			"1:41: 3 values in i32[2][2] literal",
+		},
+		{
+			"too many values in row",
+			`proc main :: -> i32 {
+				m := i32[2][2]{{1, 2, 3}}
+				return 0
+			}`,
+			"1:42: 3 values in i32[2] literal",
+		},
+		{
+			"constant index out of bounds",
+			`proc main :: -> i32 {
+				m := i32[2][3]{}
+				return m[1][3]
+			}`,
+			"2:17: index 3 out of bounds for i32[3]",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestArrayValues(t *testing.T) {
+	code := `
+		proc first :: i32[2] xs -> i32[2] {
+			return xs
+		}
+		proc main :: -> i32 {
+			i32[2] a = i32[2]{1, 2}
+			i32[2] b = a
+			b = first(a)
+			equal := a == b
+			return 0
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	statements := tree.Functions[1].Body.Statements
+
+	b := statements[1].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if _, ok := b.Value.(*ast.IdentExpression); !ok {
+		t.Errorf("Expected the array to be copied without a cast, got %s", pp.Sprint(b.Value))
+	}
+
+	assignment := statements[2].(*ast.AssignmentStatement)
+	call, ok := assignment.Right.(*ast.CallExpression)
+	expected := types.NewFunction(types.NewArray(types.IntType(32), 2), types.NewArray(types.IntType(32), 2))
+	if !ok || !reflect.DeepEqual(call.Type, expected) {
+		t.Errorf("Expected call of %s, got %s", expected, pp.Sprint(assignment.Right))
+	}
+
+	equal := statements[3].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if equal.Type != types.BasicBool {
+		t.Errorf("Expected array comparison to be bool, got %s", equal.Type)
+	}
+}
+
+func TestArrayValueErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"ordered comparison",
+			`proc main :: -> i32 {
+				a := i32[2]{1, 2}
+				if a < a {
+					return 1
+				}
+				return 0
+			}`,
+			"2:8: operator < not defined on i32[2]",
+		},
+		{
+			"comparison of different lengths",
+			`proc main :: -> i32 {
+				a := i32[2]{1, 2}
+				b := i32[3]{1, 2, 3}
+				if a == b {
+					return 1
+				}
+				return 0
+			}`,
+			"3:8: mismatched types i32[2] and i32[3]",
+		},
+		{
+			"comparison of incomparable elements",
+			`type Point struct { i32 x, i32 y }
+			proc main :: -> i32 {
+				a := Point[1]{}
+				if a == a {
+					return 1
+				}
+				return 0
+			}`,
+			"3:8: operator == not defined on Point[1]",
+		},
+		{
+			"assignment of different lengths",
+			`proc main :: -> i32 {
+				a := i32[2]{1, 2}
+				a = i32[3]{1, 2, 3}
+				return 0
+			}`,
+			"2:15: cannot use i32[3] as i32[2]",
+		},
+		{
+			"argument of different element type",
+			`proc sum :: i32[2] xs -> i32 {
+				return xs[0] + xs[1]
+			}
+			proc main :: -> i32 {
+				return sum(f64[2]{1.0, 2.0})
+			}`,
+			"3:47: cannot use f64[2] as i32[2]",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	code := `
+		type Vec struct { f64 x, f64 y }
+		func clamp :: i32 x -> i32 {
+			return max(0, min(x, 10))
+		}
+		proc main :: -> i32 {
+			a := i32[5]{}
+			s := make(i32[], 3)
+			x := i8(3)
+			n := len(a)
+			c := cap(s)
+			size := sizeof(Vec)
+			small := min(x, 2, 1)
+			big := max(1, 2.5)
+			distance := abs(0 - n)
+			return clamp(n)
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	statements := tree.Functions[1].Body.Statements
+
+	cases := []struct {
+		name  string
+		typ   types.Type
+		value string
+	}{
+		{"n", intType, "5"},
+		{"c", intType, ""},
+		{"size", intType, "16"},
+		{"small", types.IntType(8), ""},
+		{"big", floatType, ""},
+		{"distance", intType, ""},
+	}
+
+	for i, c := range cases {
+		dcl := statements[i+3].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+		if !reflect.DeepEqual(dcl.Type, c.typ) {
+			t.Errorf("Expected %s to have type %s, got %s", c.name, c.typ, dcl.Type)
+		}
+
+		switch value := dcl.Value.(type) {
+		case *ast.LiteralExpression:
+			if value.Value.Value() != c.value {
+				t.Errorf("Expected %s to be the constant %s, got %s", c.name, c.value, value.Value.Value())
+			}
+		case *ast.CallExpression:
+			if c.value != "" || value.Builtin == nil || value.Builtin.Name != dcl.Value.First().Value() {
+				t.Errorf("Expected %s to be a builtin call, got %s", c.name, pp.Sprint(value))
+			}
+		default:
+			t.Errorf("Expected %s to be a builtin call or constant, got %s", c.name, pp.Sprint(value))
+		}
+	}
+}
+
+func TestBuiltinShadowed(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+	}{
+		{
+			"len",
+			`proc len :: i32[] items -> i32 {
+				return 0
+			}
+			proc main :: -> i32 {
+				return len(make(i32[], 3))
+			}`,
+		},
+		{
+			"make declared after use",
+			`proc main :: -> i32 {
+				return make(123)
+			}
+			proc make :: i32 a -> i32 {
+				return a
+			}`,
+		},
+		{
+			"new as a local",
+			`proc main :: -> i32 {
+				new := proc :: i32 a -> i32 {
+					return a
+				}
+				return new(123)
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.NewLexer([]byte(c.code)).Lex()
+		if err != nil {
+			t.Error(err)
+		}
+
+		tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+
+		for _, f := range tree.Functions {
+			if f.Name.Value.Value() != "main" {
+				continue
+			}
+			statements := f.Body.Statements
+			result := statements[len(statements)-1].(*ast.ReturnStatement).Result
+			if call, ok := result.(*ast.CallExpression); !ok || call.Builtin != nil || call.Type == nil {
+				t.Errorf("%s: Expected a call of the declared proc, got %s", c.name, pp.Sprint(result))
+			}
+		}
+	}
+}
+
+func TestBuiltinErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"len of integer",
+			`proc main :: -> i32 {
+				return len(3)
+			}`,
+			"1:34: cannot take len of int",
+		},
+		{
+			"min of one value",
+			`proc main :: -> i32 {
+				return min(3)
+			}`,
+			"1:34: min expects at least two numbers",
+		},
+		{
+			"max of pointers",
+			`proc main :: -> i32 {
+				a := new(i32)
+				b := max(a, a)
+				return 0
+			}`,
+			"2:10: cannot take max of *i32",
+		},
+		{
+			"abs of array",
+			`proc main :: -> i32 {
+				a := abs(i32[2]{1, 2})
+				return 0
+			}`,
+			"1:32: cannot take abs of i32[2]",
+		},
+		{
+			"builtin as value",
+			`proc main :: -> i32 {
+				f := abs
+				return 0
+			}`,
+			"1:32: builtin \"abs\" must be called",
+		},
+		{
+			"pure allocation",
+			`func leak :: -> i32 {
+				p := new(i32)
+				return 0
+			}
+			proc main :: -> i32 {
+				return leak()
+			}`,
+			"1:32: func \"leak\" calls builtin \"new\"",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestBool(t *testing.T) {
+	code := `
+		proc even :: int n -> bool {
+			return n % 2 == 0
+		}
+		proc main :: -> i32 {
+			bool flag = true
+			less := 1.5 < 2.0
+			same := flag == even(3)
+			flags := bool[2]{false, 2 >= 1}
+			return 0
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	statements := tree.Functions[1].Body.Statements
+
+	cases := []struct {
+		name string
+		typ  types.Type
+	}{
+		{"flag", types.BasicBool},
+		{"less", types.BasicBool},
+		{"same", types.BasicBool},
+		{"flags", types.NewArray(types.BasicBool, 2)},
+	}
+
+	for i, c := range cases {
+		dcl := statements[i].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+		if !reflect.DeepEqual(dcl.Type, c.typ) {
+			t.Errorf("Expected %s to have type %s, got %s", c.name, c.typ, dcl.Type)
+		}
+	}
+
+	less := statements[1].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration).Value.(*ast.BinaryExpression)
+	if !less.IsFp {
+		t.Errorf("Expected float comparison to compare floats")
+	}
+}
+
+func TestBoolErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"bool arithmetic",
+			`proc main :: -> i32 {
+				a := true + false
+				return 0
+			}`,
+			"1:32: operator + not defined on bool",
+		},
+		{
+			"bool ordering",
+			`proc main :: -> i32 {
+				a := true < false
+				return 0
+			}`,
+			"1:32: operator < not defined on bool",
+		},
+		{
+			"negated bool",
+			`proc main :: -> i32 {
+				a := -true
+				return 0
+			}`,
+			"1:32: operator - not defined on bool",
+		},
+		{
+			"int assigned to bool",
+			`proc main :: -> i32 {
+				bool a = 1
+				return 0
+			}`,
+			"1:36: cannot use int as bool",
+		},
+		{
+			"bool returned as int",
+			`proc main :: -> i32 {
+				return 1 == 1
+			}`,
+			"1:34: cannot use bool as i32",
+		},
+		{
+			"bool compared with int",
+			`proc main :: -> i32 {
+				a := true == 1
+				return 0
+			}`,
+			"1:40: cannot use int as bool",
+		},
+		{
+			"int converted to bool",
+			`proc main :: -> i32 {
+				a := bool(1)
+				return 0
+			}`,
+			"1:32: cannot convert int to bool",
+		},
+		{
+			"int in bool array",
+			`proc main :: -> i32 {
+				a := bool[2]{1, 0}
+				return 0
+			}`,
+			"1:40: cannot use int as bool",
+		},
+		{
+			"int condition",
+			`proc main :: -> i32 {
+				if 1 {
+					return 1
+				}
+				return 0
+			}`,
+			"1:30: non-bool condition of type int",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
+
+func TestRunes(t *testing.T) {
+	code := `
+		proc main :: -> i32 {
+			rune letter = 'a'
+			accent := 'é'
+			newline := '\n'
+			offset := letter - 'a'
+			return 0
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	statements := tree.Functions[0].Body.Statements
+
+	cases := []struct {
+		name  string
+		value string
+	}{
+		{"accent", "233"},
+		{"newline", "10"},
+	}
+
+	for i, c := range cases {
+		dcl := statements[i+1].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+		if !reflect.DeepEqual(dcl.Type, runeType) {
+			t.Errorf("Expected %s to have type rune, got %s", c.name, dcl.Type)
+		}
+
+		cast, ok := dcl.Value.(*ast.CastExpression)
+		if !ok {
+			t.Errorf("Expected %s to be a rune constant, got %s", c.name, pp.Sprint(dcl.Value))
+			continue
+		}
+		if value := cast.Expression.(*ast.LiteralExpression).Value.Value(); value != c.value {
+			t.Errorf("Expected %s to be %s, got %s", c.name, c.value, value)
+		}
+	}
+
+	offset := statements[3].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if !reflect.DeepEqual(offset.Type, runeType) {
+		t.Errorf("Expected offset to have type rune, got %s", offset.Type)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	code := `
+		proc main :: -> i32 {
+			word := "héllo"
+			first := rune(word)
+			letter := string(first)
+			n := len(letter)
+			return 0
+		}
+	`
+
+	tokens, err := lexer.NewLexer([]byte(code)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	statements := tree.Functions[0].Body.Statements
+
+	cases := []struct {
+		name string
+		typ  types.Type
+	}{
+		{"word", types.BasicString},
+		{"first", runeType},
+		{"letter", types.BasicString},
+		{"n", intType},
+	}
+
+	for i, c := range cases {
+		dcl := statements[i].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+		if !reflect.DeepEqual(dcl.Type, c.typ) {
+			t.Errorf("Expected %s to have type %s, got %s", c.name, c.typ, dcl.Type)
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		message string
+	}{
+		{
+			"string concatenation",
+			`proc main :: -> i32 {
+				s := "a" + "b"
+				return 0
+			}`,
+			"1:32: operator + not defined on string",
+		},
+		{
+			"string comparison",
+			`proc main :: -> i32 {
+				if "a" == "b" {
+					return 1
+				}
+				return 0
+			}`,
+			"1:30: operator == not defined on string",
+		},
+		{
+			"string assigned to int",
+			`proc main :: -> i32 {
+				int n = "a"
+				return 0
+			}`,
+			"1:35: cannot use string as int",
+		},
+		{
+			"int assigned to string",
+			`proc main :: -> i32 {
+				s := "a"
+				s = 5
+				return 0
+			}`,
+			"2:9: cannot use int as string",
+		},
+		{
+			"float converted to string",
+			`proc main :: -> i32 {
+				s := string(1.5)
+				return 0
+			}`,
+			"1:32: cannot convert float to string",
+		},
+		{
+			"string converted to int",
+			`proc main :: -> i32 {
+				n := int("a")
+				return 0
+			}`,
+			"1:32: cannot convert string to int",
+		},
+		{
+			"cap of string",
+			`proc main :: -> i32 {
+				n := cap("abc")
+				return 0
+			}`,
+			"1:32: cannot take cap of string",
+		},
+		{
+			"free of string",
+			`proc main :: -> i32 {
+				s := "abc"
+				free(s)
+				return 0
+			}`,
+			"2:5: cannot free type string",
+		},
+		{
+			"pure conversion",
+			`func letter :: rune r -> string {
+				return string(r)
+			}`,
+			"1:46: func \"letter\" converts a rune to a string",
+		},
+	}
+
+	for _, c := range cases {
+		expectError(t, c.code, c.message)
+	}
+}
@@ -0,0 +1,184 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+)
+
+// allocators are the builtin procedures that use the heap, pure procedures
+// cant call them
+var allocators = map[string]bool{
+	"new":  true,
+	"make": true,
+	"free": true,
+}
+
+// builtin returns the name of the builtin procedure the call refers to, a
+// procedure declared by the program with the same name takes precedence
+func (a *Analysis) builtin(node *ast.CallExpression) (string, bool) {
+	ident, ok := node.Function.(*ast.IdentExpression)
+	if !ok {
+		return "", false
+	}
+
+	builtin, ok := a.lookup(ident.Value.Value()).(*ast.Builtin)
+	if !ok || builtin.Type != nil {
+		return "", false
+	}
+
+	return builtin.Name, true
+}
+
+// builtinTyp returns the type of a call to a builtin procedure
+func (a *Analysis) builtinTyp(name string, node *ast.CallExpression) types.Type {
+	switch name {
+	case "new":
+		return types.NewPointer(a.typ(node.Arguments.Elements[0]))
+	case "make":
+		return a.typ(node.Arguments.Elements[0])
+	case "len", "cap", "sizeof":
+		return intType
+	case "min", "max":
+		return a.extremumType(node.Arguments.Elements)
+	case "abs":
+		return a.typ(node.Arguments.Elements[0])
+	}
+
+	return nil
+}
+
+// extremumType returns the type min and max compare their arguments in, the
+// type of the first argument that isnt a constant
+func (a *Analysis) extremumType(arguments []ast.Expression) types.Type {
+	for _, arg := range arguments {
+		if _, constant := a.evaluate(arg); !constant {
+			return a.typ(arg)
+		}
+	}
+
+	for _, arg := range arguments {
+		if a.typ(arg) == floatType {
+			return floatType
+		}
+	}
+
+	return a.typ(arguments[0])
+}
+
+// numeric returns true if the type is an integer or float type
+func numeric(typ types.Type) bool {
+	basic, ok := types.Underlying(typ).(*types.Basic)
+	return ok && basic.Info()&types.IsNumeric != 0
+}
+
+// intLiteral returns an integer literal with the value at the position of the node
+func intLiteral(node ast.Node, value int64) *ast.LiteralExpression {
+	first := node.First()
+	return &ast.LiteralExpression{
+		Value: lexer.NewToken(lexer.INT, strconv.FormatInt(value, 10), first.Line(), first.Column()),
+	}
+}
+
+// builtinCallExp checks the arguments of a call to a builtin procedure
+func (a *Analysis) builtinCallExp(name string, node *ast.CallExpression) ast.Expression {
+	arguments := node.Arguments.Elements
+
+	newCallExp := &ast.CallExpression{
+		Function: node.Function,
+		Arguments: &ast.ParenLiteralExpression{
+			LeftParen:  node.Arguments.LeftParen,
+			Elements:   make([]ast.Expression, len(arguments)),
+			RightParen: node.Arguments.RightParen,
+		},
+		Builtin: a.lookup(name).(*ast.Builtin),
+	}
+
+	switch name {
+	case "new":
+		if len(arguments) != 1 {
+			panic(a.newError(node, "new expects a single type argument"))
+		}
+		newCallExp.Arguments.Elements[0] = &ast.TypeExpression{Type: a.typ(arguments[0])}
+
+	case "make":
+		if len(arguments) != 2 {
+			panic(a.newError(node, "make expects a slice type and a length"))
+		}
+		if _, ok := a.typ(arguments[0]).(*types.Slice); !ok {
+			panic(a.newError(node, fmt.Sprintf("cannot make type %s", a.typ(arguments[0]))))
+		}
+		newCallExp.Arguments.Elements[0] = &ast.TypeExpression{Type: a.typ(arguments[0])}
+		newCallExp.Arguments.Elements[1] = a.convert(a.expression(arguments[1]), types.IntType(64))
+
+	case "free":
+		if len(arguments) != 1 {
+			panic(a.newError(node, "free expects a single pointer, slice or function value"))
+		}
+		newArg := a.expression(arguments[0])
+		switch a.typ(newArg).(type) {
+		case *types.Pointer, *types.Slice:
+		case *types.Function:
+			// Function values free the environment of the lambda they came from
+		default:
+			panic(a.newError(node, fmt.Sprintf("cannot free type %s", a.typ(newArg))))
+		}
+		newCallExp.Arguments.Elements[0] = newArg
+		newCallExp.Type = types.NewFunction(nil, a.typ(newArg))
+
+	case "len", "cap":
+		if len(arguments) != 1 {
+			panic(a.newError(node, fmt.Sprintf("%s expects a single array or slice", name)))
+		}
+		newArg := a.expression(arguments[0])
+		switch typ := types.Underlying(a.typ(newArg)).(type) {
+		case *types.Array:
+			// The length of an array is a constant, the array isnt evaluated
+			return intLiteral(node, int64(typ.Length()))
+		case *types.Slice:
+		default:
+			// Strings have a length but no capacity
+			if name != "len" || !textual(typ) {
+				panic(a.newError(node, fmt.Sprintf("cannot take %s of %s", name, a.typ(newArg))))
+			}
+		}
+		newCallExp.Arguments.Elements[0] = newArg
+
+	case "sizeof":
+		if len(arguments) != 1 {
+			panic(a.newError(node, "sizeof expects a single type or value"))
+		}
+		return intLiteral(node, types.Sizeof(a.typ(arguments[0])))
+
+	case "min", "max":
+		if len(arguments) < 2 {
+			panic(a.newError(node, fmt.Sprintf("%s expects at least two numbers", name)))
+		}
+		newArgs := make([]ast.Expression, len(arguments))
+		for i, arg := range arguments {
+			newArgs[i] = a.expression(arg)
+		}
+		typ := a.extremumType(newArgs)
+		if !numeric(typ) {
+			panic(a.newError(node, fmt.Sprintf("cannot take %s of %s", name, typ)))
+		}
+		for i, arg := range newArgs {
+			newCallExp.Arguments.Elements[i] = a.convert(arg, typ)
+		}
+
+	case "abs":
+		if len(arguments) != 1 {
+			panic(a.newError(node, "abs expects a single number"))
+		}
+		newArg := a.expression(arguments[0])
+		if !numeric(a.typ(newArg)) {
+			panic(a.newError(node, fmt.Sprintf("cannot take abs of %s", a.typ(newArg))))
+		}
+		newCallExp.Arguments.Elements[0] = newArg
+	}
+
+	return newCallExp
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+)
+
+// functionType returns the type of an expression that names a function or
+// composes functions
+func (a *Analysis) functionType(node ast.Expression) (*types.Function, bool) {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		f, ok := a.lookup(node.Value.Value()).(*ast.FunctionDeclaration)
+		if !ok {
+			return nil, false
+		}
+		return a.typ(f).(*types.Function), true
+
+	case *ast.CompositionExpression:
+		return node.Type, true
+	}
+
+	return nil, false
+}
+
+// isComposition returns true if the binary expression is a composition of
+// two functions rather than a shift
+func (a *Analysis) isComposition(node *ast.BinaryExpression) bool {
+	if node.Operator.Type() != lexer.SHR {
+		return false
+	}
+
+	if left, ok := node.Left.(*ast.BinaryExpression); ok {
+		return a.isComposition(left)
+	}
+
+	_, ok := a.functionType(node.Left)
+	return ok
+}
+
+// compositionExp runs analysis on a composition, the function on the right
+// must take the result of the function on the left as its only argument
+func (a *Analysis) compositionExp(node *ast.BinaryExpression) ast.Expression {
+	left := a.expression(node.Left)
+	right := a.expression(node.Right)
+
+	leftType, ok := a.functionType(left)
+	if !ok {
+		panic(a.newError(node, "cannot compose a non-function"))
+	}
+	rightType, ok := a.functionType(right)
+	if !ok {
+		panic(a.newError(node.Right, "cannot compose a non-function"))
+	}
+
+	if leftType.Return() == nil {
+		panic(a.newError(node, fmt.Sprintf("cannot compose %s, it returns no value", leftType)))
+	}
+
+	if len(rightType.Arguments()) != 1 || !sameType(leftType.Return(), rightType.Arguments()[0]) {
+		panic(a.newError(node, fmt.Sprintf("cannot compose %s with %s", leftType, rightType)))
+	}
+
+	return &ast.CompositionExpression{
+		Left:     left,
+		Operator: node.Operator,
+		Right:    right,
+		Type:     types.NewFunction(rightType.Return(), leftType.Arguments()...),
+	}
+}
+
+// pipelineExp desugars a pipeline stage into a call of the function on the
+// right with the value on the left
+func (a *Analysis) pipelineExp(node *ast.BinaryExpression) ast.Expression {
+	value := a.expression(node.Left)
+	stage := a.expression(node.Right)
+
+	stageType, ok := a.functionType(stage)
+	if !ok {
+		panic(a.newError(node.Right, "cannot pipe into a non-function"))
+	}
+
+	if len(stageType.Arguments()) != 1 {
+		panic(a.newError(node.Right, fmt.Sprintf("pipeline stage %s must take one argument", stageType)))
+	}
+
+	// Constants are converted like any other argument, values coming out of
+	// earlier stages must already match
+	argType := stageType.Arguments()[0]
+	if typ := a.typ(value); !sameType(typ, argType) {
+		if _, ok := a.evaluate(value); !ok {
+			panic(a.newError(node.Right, fmt.Sprintf("pipeline stage expects %s, got %s", argType, typ)))
+		}
+		value = &ast.CastExpression{
+			Expression: value,
+			Type:       argType,
+		}
+	}
+
+	call := &ast.CallExpression{
+		Function: stage,
+		Arguments: &ast.ParenLiteralExpression{
+			Elements: []ast.Expression{value},
+		},
+		Type: stageType,
+	}
+
+	if a.pure() {
+		a.pureCall(call)
+	}
+
+	return call
+}
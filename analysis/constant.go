@@ -0,0 +1,455 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+)
+
+// constant is the value of an expression known at compile time
+type constant struct {
+	typ *types.Basic
+	i   int64
+	f   float64
+}
+
+func (c constant) isFloat() bool {
+	return c.typ.Info()&types.IsFloat != 0
+}
+
+// wrap truncates the value to the width of the integer type, wrapping around
+// like the operation would at runtime
+func wrap(value int64, typ *types.Basic) int64 {
+	shift := 64 - uint(types.Sizeof(typ)*8)
+	return value << shift >> shift
+}
+
+// convert returns the constant as the type
+func (c constant) convert(typ *types.Basic) (constant, bool) {
+	switch {
+	case typ.Info()&types.IsInt != 0:
+		value := c.i
+		if c.isFloat() {
+			value = int64(c.f)
+		}
+		return constant{typ: typ, i: wrap(value, typ)}, true
+
+	case typ.Info()&types.IsFloat != 0:
+		value := c.f
+		if !c.isFloat() {
+			value = float64(c.i)
+		}
+		if types.Sizeof(typ) == 4 {
+			value = float64(float32(value))
+		}
+		return constant{typ: typ, f: value}, true
+	}
+
+	return constant{}, false
+}
+
+// representable reports an error if the constant expression overflows the
+// integer type it is converted to
+func (a *Analysis) representable(node ast.Expression, typ types.Type) {
+	basic, ok := types.Underlying(typ).(*types.Basic)
+	if !ok || basic.Info()&types.IsInt == 0 {
+		return
+	}
+
+	if value, ok := a.evaluate(node); ok && !value.isFloat() && wrap(value.i, basic) != value.i {
+		panic(a.newError(node, fmt.Sprintf("constant %d overflows %s", value.i, typ)))
+	}
+}
+
+// evaluate returns the value of the expression if it can be computed at
+// compile time
+func (a *Analysis) evaluate(node ast.Expression) (constant, bool) {
+	switch node := node.(type) {
+	case *ast.LiteralExpression:
+		switch node.Value.Type() {
+		case lexer.INT:
+			value, err := strconv.ParseInt(node.Value.Value(), 0, 64)
+			return constant{typ: intType, i: value}, err == nil
+		case lexer.FLOAT:
+			value, err := strconv.ParseFloat(node.Value.Value(), 64)
+			return constant{typ: floatType, f: value}, err == nil
+		case lexer.CHAR:
+			source := node.Value.Value()
+			value, _, _, err := strconv.UnquoteChar(source[1:len(source)-1], '\'')
+			return constant{typ: runeType, i: int64(value)}, err == nil
+		}
+
+	case *ast.UnaryExpression:
+		value, ok := a.evaluate(node.Expression)
+		if !ok {
+			return value, false
+		}
+
+		switch node.Operator.Type() {
+		case lexer.ADD:
+			return value, true
+		case lexer.SUB:
+			if value.isFloat() {
+				value.f = -value.f
+			} else {
+				value.i = wrap(-value.i, value.typ)
+			}
+			return value, true
+		}
+
+	case *ast.CastExpression:
+		typ, ok := node.Type.(*types.Basic)
+		if !ok {
+			return constant{}, false
+		}
+
+		value, ok := a.evaluate(node.Expression)
+		if !ok {
+			return value, false
+		}
+		return value.convert(typ)
+
+	case *ast.BinaryExpression:
+		left, ok := a.evaluate(node.Left)
+		if !ok {
+			return left, false
+		}
+		right, ok := a.evaluate(node.Right)
+		if !ok || left.typ.Type() != right.typ.Type() {
+			return right, false
+		}
+
+		if left.isFloat() {
+			return a.evaluateFloat(node, left, right)
+		}
+		return a.evaluateInt(node, left, right)
+	}
+
+	return constant{}, false
+}
+
+func (a *Analysis) evaluateInt(node *ast.BinaryExpression, left, right constant) (constant, bool) {
+	result := constant{typ: left.typ}
+
+	switch node.Operator.Type() {
+	case lexer.ADD:
+		result.i = left.i + right.i
+	case lexer.SUB:
+		result.i = left.i - right.i
+	case lexer.MUL:
+		result.i = left.i * right.i
+	case lexer.QUO, lexer.REM:
+		if right.i == 0 {
+			panic(a.newError(node, "division by zero"))
+		}
+		if node.Operator.Type() == lexer.QUO {
+			result.i = left.i / right.i
+		} else {
+			result.i = left.i % right.i
+		}
+	default:
+		return result, false
+	}
+
+	result.i = wrap(result.i, result.typ)
+	return result, true
+}
+
+func (a *Analysis) evaluateFloat(node *ast.BinaryExpression, left, right constant) (constant, bool) {
+	result := constant{typ: left.typ}
+
+	switch node.Operator.Type() {
+	case lexer.ADD:
+		result.f = left.f + right.f
+	case lexer.SUB:
+		result.f = left.f - right.f
+	case lexer.MUL:
+		result.f = left.f * right.f
+	case lexer.QUO:
+		result.f = left.f / right.f
+	default:
+		return result, false
+	}
+
+	return result.convert(result.typ)
+}
+
+// literal creates an expression for the constant at the position of the node
+// it replaces, types other than int and float are kept with a cast
+func literal(value constant, position lexer.Token) ast.Expression {
+	var token lexer.Token
+	if value.isFloat() {
+		token = lexer.NewToken(lexer.FLOAT, strconv.FormatFloat(value.f, 'g', -1, 64),
+			position.Line(), position.Column())
+	} else {
+		token = lexer.NewToken(lexer.INT, strconv.FormatInt(value.i, 10),
+			position.Line(), position.Column())
+	}
+
+	exp := ast.Expression(&ast.LiteralExpression{Value: token})
+	if value.typ.Type() != types.Int && value.typ.Type() != types.Float {
+		exp = &ast.CastExpression{
+			Expression: exp,
+			Type:       value.typ,
+		}
+	}
+
+	return exp
+}
+
+// fold replaces constant expressions with their value
+func (a *Analysis) fold(node ast.Expression) ast.Expression {
+	switch node := node.(type) {
+	case *ast.BinaryExpression:
+		node.Left = a.fold(node.Left)
+		node.Right = a.fold(node.Right)
+
+		switch node.Operator.Type() {
+		case lexer.QUO, lexer.REM:
+			if divisor, ok := a.evaluate(node.Right); ok && divisor.i == 0 && divisor.f == 0 {
+				panic(a.newError(node, "division by zero"))
+			}
+		}
+
+	case *ast.UnaryExpression:
+		node.Expression = a.fold(node.Expression)
+
+	case *ast.CastExpression:
+		node.Expression = a.fold(node.Expression)
+
+	case *ast.CallExpression:
+		a.foldAll(node.Arguments.Elements)
+		return node
+
+	case *ast.IndexExpression:
+		node.Expression = a.fold(node.Expression)
+		node.Index = a.fold(node.Index)
+		return node
+
+	case *ast.ParenLiteralExpression:
+		a.foldAll(node.Elements)
+		return node
+
+	case *ast.BraceLiteralExpression:
+		a.foldAll(node.Elements)
+		return node
+
+	case *ast.LambdaExpression:
+		a.foldStatement(node.Function.Body)
+		return node
+
+	case *ast.VariantExpression:
+		if node.Arguments != nil {
+			a.foldAll(node.Arguments.Elements)
+		}
+		return node
+
+	case *ast.FieldExpression:
+		node.Expression = a.fold(node.Expression)
+		return node
+
+	case *ast.MethodCallExpression:
+		node.Receiver = a.fold(node.Receiver)
+		a.foldAll(node.Arguments.Elements)
+		return node
+
+	case *ast.InterfaceExpression:
+		node.Expression = a.fold(node.Expression)
+		return node
+
+	case *ast.MatchExpression:
+		node.Value = a.fold(node.Value)
+		for _, clause := range node.Clauses {
+			clause.Result = a.fold(clause.Result)
+		}
+		return node
+
+	default:
+		return node
+	}
+
+	if value, ok := a.evaluate(node); ok {
+		return literal(value, position(node))
+	}
+
+	return node
+}
+
+// position returns the first token of the expression, casts inserted by
+// analysis have no tokens of their own
+func position(node ast.Expression) lexer.Token {
+	if cast, ok := node.(*ast.CastExpression); ok && cast.LeftParen.Type() == lexer.ILLEGAL {
+		return position(cast.Expression)
+	}
+
+	return node.First()
+}
+
+func (a *Analysis) foldAll(nodes []ast.Expression) {
+	for i, node := range nodes {
+		nodes[i] = a.fold(node)
+	}
+}
+
+// foldStatement folds every expression in the statement
+func (a *Analysis) foldStatement(node ast.Statement) {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		for _, smt := range node.Statements {
+			a.foldStatement(smt)
+		}
+
+	case *ast.AssignmentStatement:
+		node.Left = a.fold(node.Left)
+		node.Right = a.fold(node.Right)
+
+	case *ast.ReturnStatement:
+		if node.Result != nil {
+			node.Result = a.fold(node.Result)
+		}
+
+	case *ast.IfStatment:
+		if node.Condition != nil {
+			node.Condition = a.fold(node.Condition)
+		}
+		a.foldStatement(node.Body)
+		if node.Else != nil {
+			a.foldStatement(node.Else)
+		}
+
+	case *ast.ForStatement:
+		a.foldStatement(node.Index)
+		node.Condition = a.fold(node.Condition)
+		a.foldStatement(node.Increment)
+		a.foldStatement(node.Body)
+
+	case *ast.SwitchStatement:
+		if node.Tag != nil {
+			node.Tag = a.fold(node.Tag)
+		}
+		for _, clause := range node.Clauses {
+			a.foldAll(clause.Expressions)
+			a.foldStatement(clause.Body)
+		}
+
+	case *ast.MatchStatement:
+		node.Value = a.fold(node.Value)
+		for _, clause := range node.Clauses {
+			a.foldStatement(clause.Body)
+		}
+
+	case *ast.ExpressionStatement:
+		node.Expression = a.fold(node.Expression)
+
+	case *ast.DeferStatement:
+		a.fold(node.Call)
+
+	case *ast.DeclareStatement:
+		switch decl := node.Statement.(type) {
+		case *ast.VaribleDeclaration:
+			decl.Value = a.fold(decl.Value)
+		case *ast.TupleDeclaration:
+			decl.Value = a.fold(decl.Value)
+		}
+	}
+}
+
+// constantDcl runs analysis on a constant declaration, the value must be known
+// at compile time and is substituted wherever the constant is used
+func (a *Analysis) constantDcl(node *ast.ConstantDeclaration) ast.Declare {
+	value := a.expression(node.Value)
+	if node.Type != nil {
+		a.castable(node.Value, a.typ(value), a.resolve(node.Type))
+		value = &ast.CastExpression{
+			Type:       a.resolve(node.Type),
+			Expression: value,
+		}
+	}
+	value = a.fold(value)
+
+	if _, ok := a.evaluate(value); !ok {
+		panic(a.newError(node, fmt.Sprintf("value of const %q is not a constant",
+			node.Name.Value.Value())))
+	}
+
+	newConstantDcl := &ast.ConstantDeclaration{
+		Const: node.Const,
+		Name:  node.Name,
+		Type:  a.typ(value),
+		Value: value,
+	}
+
+	if a.currentBlock != nil {
+		a.currentBlock.Scope.Replace(node.Name.Value.Value(), newConstantDcl)
+	}
+
+	return newConstantDcl
+}
+
+// lengths sets the length of every array type declared with a constant
+// expression as its length
+func (a *Analysis) lengths() {
+	if a.root.Scope != nil {
+		a.currentBlock = &ast.BlockStatement{Scope: a.root.Scope}
+	}
+
+	for _, length := range a.root.Lengths {
+		value, ok := a.evaluate(a.constantExp(length.Length))
+		if !ok || value.isFloat() || value.i < 0 {
+			panic(a.newError(length.Length, "array length must be a non-negative integer constant"))
+		}
+		length.Array.SetLength(value.i)
+	}
+
+	a.currentBlock = nil
+}
+
+// constantExp replaces the names of constants in the expression with the
+// values they were declared with, so it can be evaluated before the constants
+// are analysed
+func (a *Analysis) constantExp(node ast.Expression) ast.Expression {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		if dcl, ok := a.lookup(node.Value.Value()).(*ast.ConstantDeclaration); ok {
+			return a.constantExp(dcl.Value)
+		}
+
+	case *ast.UnaryExpression:
+		return &ast.UnaryExpression{
+			Operator:   node.Operator,
+			Expression: a.constantExp(node.Expression),
+		}
+
+	case *ast.BinaryExpression:
+		return &ast.BinaryExpression{
+			Left:     a.constantExp(node.Left),
+			Operator: node.Operator,
+			Right:    a.constantExp(node.Right),
+		}
+	}
+
+	return node
+}
+
+// isConstant returns true if the expression names a constant
+func (a *Analysis) isConstant(node ast.Expression) bool {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		if a.currentBlock == nil {
+			return false
+		}
+		_, ok := a.currentBlock.Scope.Lookup(node.Value.Value()).(*ast.ConstantDeclaration)
+		return ok
+	case *ast.ParenLiteralExpression:
+		for _, element := range node.Elements {
+			if a.isConstant(element) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,318 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+)
+
+// resolve replaces the type parameters in the type with the type arguments of
+// the instance being analysed
+func (a *Analysis) resolve(typ types.Type) types.Type {
+	if len(a.typeArguments) == 0 || typ == nil {
+		return typ
+	}
+
+	return types.Substitute(typ, a.typeArguments)
+}
+
+// generic returns the generic procedure the expression names
+func (a *Analysis) generic(node ast.Expression) (*ast.FunctionDeclaration, bool) {
+	ident, ok := node.(*ast.IdentExpression)
+	if !ok {
+		return nil, false
+	}
+
+	f, ok := a.lookup(ident.Value.Value()).(*ast.FunctionDeclaration)
+	return f, ok && len(f.TypeParameters) > 0
+}
+
+// bindings maps the names of the procedures type parameters to the type arguments
+func bindings(f *ast.FunctionDeclaration, typeArguments []types.Type) map[string]types.Type {
+	bound := make(map[string]types.Type)
+	for i, parameter := range f.TypeParameters {
+		bound[parameter.Name()] = typeArguments[i]
+	}
+
+	return bound
+}
+
+// infer binds the type parameters in the parameter type to the matching parts
+// of the argument type, returning false if they conflict
+func infer(param, arg types.Type, bound map[string]types.Type) bool {
+	switch param := param.(type) {
+	case *types.Parameter:
+		if typ, ok := bound[param.Name()]; ok {
+			return sameType(typ, arg)
+		}
+		bound[param.Name()] = arg
+		return true
+
+	case *types.Array:
+		array, ok := arg.(*types.Array)
+		return ok && array.Length() == param.Length() && infer(param.Type(), array.Type(), bound)
+
+	case *types.Slice:
+		slice, ok := arg.(*types.Slice)
+		return ok && infer(param.Type(), slice.Type(), bound)
+
+	case *types.Pointer:
+		pointer, ok := arg.(*types.Pointer)
+		return ok && infer(param.Type(), pointer.Type(), bound)
+	}
+
+	return true
+}
+
+// genericCallExp infers the type arguments of a call to a generic procedure
+// from its arguments and instantiates the procedure with them
+func (a *Analysis) genericCallExp(node *ast.CallExpression, f *ast.FunctionDeclaration) ast.Expression {
+	name := f.Name.Value.Value()
+	if len(node.Arguments.Elements) != len(f.Arguments) {
+		panic(a.newError(node, fmt.Sprintf("proc %q expects %d arguments, got %d",
+			name, len(f.Arguments), len(node.Arguments.Elements))))
+	}
+
+	arguments := make([]ast.Expression, len(node.Arguments.Elements))
+	for i, arg := range node.Arguments.Elements {
+		arguments[i] = a.expression(arg)
+	}
+
+	// Constants only decide a type argument no other argument does
+	bound := make(map[string]types.Type)
+	for i, arg := range arguments {
+		if _, constant := a.evaluate(arg); constant {
+			continue
+		}
+		if !infer(f.Arguments[i].Type, a.typ(arg), bound) {
+			panic(a.newError(arg, fmt.Sprintf("cannot use %s as %s in call to proc %q",
+				a.typ(arg), f.Arguments[i].Type, name)))
+		}
+	}
+	for i, arg := range arguments {
+		if _, constant := a.evaluate(arg); constant {
+			infer(f.Arguments[i].Type, a.typ(arg), bound)
+		}
+	}
+
+	typeArguments := make([]types.Type, len(f.TypeParameters))
+	for i, parameter := range f.TypeParameters {
+		typ, ok := bound[parameter.Name()]
+		if !ok {
+			panic(a.newError(node, fmt.Sprintf("cannot infer type parameter %s of proc %q",
+				parameter, name)))
+		}
+		if !parameter.Satisfies(typ) {
+			panic(a.newError(node, fmt.Sprintf("%s does not satisfy the constraint on type parameter %s of proc %q",
+				typ, parameter, name)))
+		}
+		typeArguments[i] = typ
+	}
+
+	a.instantiate(f, typeArguments)
+
+	argTypes := make([]types.Type, len(arguments))
+	for i, arg := range arguments {
+		argTypes[i] = types.Substitute(f.Arguments[i].Type, bound)
+		arguments[i] = a.convert(arg, argTypes[i])
+	}
+
+	var returnType types.Type
+	if f.Return != nil {
+		returnType = types.Substitute(f.Return, bound)
+	}
+
+	return &ast.CallExpression{
+		Function: node.Function,
+		Arguments: &ast.ParenLiteralExpression{
+			LeftParen:  node.Arguments.LeftParen,
+			Elements:   arguments,
+			RightParen: node.Arguments.RightParen,
+		},
+		TypeArguments: typeArguments,
+		Type:          types.NewFunction(returnType, argTypes...),
+	}
+}
+
+// genericReturn returns the return type of the instance a call refers to
+func (a *Analysis) genericReturn(node *ast.CallExpression) types.Type {
+	f, _ := a.generic(node.Function)
+	if f.Return == nil {
+		return nil
+	}
+
+	return types.Substitute(f.Return, bindings(f, node.TypeArguments))
+}
+
+// instantiate returns the instance of the generic procedure for the type
+// arguments, the body is analysed with them substituted the first time
+func (a *Analysis) instantiate(f *ast.FunctionDeclaration, typeArguments []types.Type) *ast.FunctionDeclaration {
+	for _, instance := range f.Instances {
+		if sameTypes(instance.TypeArguments, typeArguments) {
+			return instance
+		}
+	}
+
+	// Added before the body is analysed so recursive calls find it
+	instance := &ast.FunctionDeclaration{
+		Name:          f.Name,
+		TypeArguments: typeArguments,
+	}
+	f.Instances = append(f.Instances, instance)
+
+	function, block, closures := a.currentFunction, a.currentBlock, a.closures
+	loopDepth, readsMemory, bound := a.loopDepth, a.readsMemory, a.typeArguments
+	a.closures, a.loopDepth, a.typeArguments = nil, 0, bindings(f, typeArguments)
+
+	*instance = *a.functionDcl(f).(*ast.FunctionDeclaration)
+	instance.TypeArguments = typeArguments
+
+	a.currentFunction, a.currentBlock, a.closures = function, block, closures
+	a.loopDepth, a.readsMemory, a.typeArguments = loopDepth, readsMemory, bound
+
+	return instance
+}
+
+func sameTypes(a, b []types.Type) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameType(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// declareGeneric resolves the names used in the body of a generic procedure
+// when it is declared, the type parameters are opaque so the body is only
+// type checked when it is instantiated
+func (a *Analysis) declareGeneric(f *ast.FunctionDeclaration) {
+	opaque := make(map[string]types.Type)
+	for _, parameter := range f.TypeParameters {
+		opaque[parameter.Name()] = parameter
+	}
+
+	block, bound := a.currentBlock, a.typeArguments
+	a.typeArguments = opaque
+	a.resolveStatement(f.Body)
+	a.currentBlock, a.typeArguments = block, bound
+}
+
+// resolveStatement checks every name used in the statement is declared
+func (a *Analysis) resolveStatement(node ast.Statement) {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		block := a.currentBlock
+		a.currentBlock = node
+		for _, statement := range node.Statements {
+			a.resolveStatement(statement)
+		}
+		a.currentBlock = block
+
+	case *ast.DeclareStatement:
+		switch dcl := node.Statement.(type) {
+		case *ast.VaribleDeclaration:
+			a.resolveExpression(dcl.Value)
+		case *ast.ConstantDeclaration:
+			a.resolveExpression(dcl.Value)
+		case *ast.TupleDeclaration:
+			a.resolveExpression(dcl.Value)
+		}
+
+	case *ast.AssignmentStatement:
+		a.resolveExpression(node.Left)
+		a.resolveExpression(node.Right)
+
+	case *ast.ReturnStatement:
+		a.resolveExpression(node.Result)
+
+	case *ast.ExpressionStatement:
+		a.resolveExpression(node.Expression)
+
+	case *ast.DeferStatement:
+		a.resolveExpression(node.Call)
+
+	case *ast.IfStatment:
+		for ; node != nil; node = node.Else {
+			a.resolveExpression(node.Condition)
+			a.resolveStatement(node.Body)
+		}
+
+	case *ast.ForStatement:
+		a.resolveStatement(node.Index)
+		a.resolveExpression(node.Condition)
+		a.resolveStatement(node.Increment)
+		a.resolveStatement(node.Body)
+
+	case *ast.SwitchStatement:
+		a.resolveExpression(node.Tag)
+		for _, clause := range node.Clauses {
+			for _, exp := range clause.Expressions {
+				a.resolveExpression(exp)
+			}
+			a.resolveStatement(clause.Body)
+		}
+
+	case *ast.MatchStatement:
+		a.resolveExpression(node.Value)
+		for _, clause := range node.Clauses {
+			a.resolveStatement(clause.Body)
+		}
+	}
+}
+
+// resolveExpression checks every name used in the expression is declared,
+// the selections of fields, methods and variants are resolved by their type
+func (a *Analysis) resolveExpression(node ast.Expression) {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		_, parameter := a.typeArguments[node.Value.Value()]
+		if a.lookup(node.Value.Value()) == nil && !parameter {
+			panic(a.newError(node, fmt.Sprintf("undefined: %s", node.Value.Value())))
+		}
+
+	case *ast.BinaryExpression:
+		a.resolveExpression(node.Left)
+		a.resolveExpression(node.Right)
+
+	case *ast.UnaryExpression:
+		a.resolveExpression(node.Expression)
+
+	case *ast.CallExpression:
+		a.resolveExpression(node.Function)
+		a.resolveExpression(node.Arguments)
+
+	case *ast.BraceLiteralExpression:
+		for _, element := range node.Elements {
+			a.resolveExpression(element)
+		}
+
+	case *ast.ParenLiteralExpression:
+		for _, element := range node.Elements {
+			a.resolveExpression(element)
+		}
+
+	case *ast.IndexExpression:
+		a.resolveExpression(node.Expression)
+		a.resolveExpression(node.Index)
+
+	case *ast.SelectorExpression:
+		a.resolveExpression(node.Expression)
+
+	case *ast.LambdaExpression:
+		a.resolveStatement(node.Function.Body)
+
+	case *ast.MatchExpression:
+		a.resolveExpression(node.Value)
+		block := a.currentBlock
+		for _, clause := range node.Clauses {
+			a.currentBlock = clause.Body
+			a.resolveExpression(clause.Result)
+		}
+		a.currentBlock = block
+	}
+}
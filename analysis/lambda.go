@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+)
+
+// closure is a lambda whose body is being analysed
+type closure struct {
+	lambda *ast.LambdaExpression
+	scope  *ast.Scope
+}
+
+// lambdaExp runs analysis on the body of an anonymous procedure as if it was
+// declared at the top level, recording the varibles it captures
+func (a *Analysis) lambdaExp(node *ast.LambdaExpression) ast.Expression {
+	a.lambdas++
+	node.Function.Name = &ast.IdentExpression{
+		Value: lexer.NewToken(lexer.IDENT, fmt.Sprintf("lambda.%d", a.lambdas),
+			node.Proc.Line(), node.Proc.Column()),
+	}
+
+	newLambdaExp := &ast.LambdaExpression{
+		Proc: node.Proc,
+	}
+
+	// The body is analysed as its own procedure
+	function, block := a.currentFunction, a.currentBlock
+	loopDepth, readsMemory := a.loopDepth, a.readsMemory
+	a.closures = append(a.closures, &closure{
+		lambda: newLambdaExp,
+		scope:  node.Function.Body.Scope,
+	})
+	a.loopDepth = 0
+
+	newLambdaExp.Function = a.functionDcl(node.Function).(*ast.FunctionDeclaration)
+
+	a.closures = a.closures[:len(a.closures)-1]
+	a.currentFunction, a.currentBlock = function, block
+	a.loopDepth, a.readsMemory = loopDepth, readsMemory
+
+	if a.pure() && len(newLambdaExp.Captures) > 0 {
+		a.impure(node, "creates a closure")
+	}
+
+	return newLambdaExp
+}
+
+// capture records the varible in every lambda being analysed that it was
+// declared outside of
+func (a *Analysis) capture(name lexer.Token, decl ast.Node) {
+	if _, ok := decl.(*ast.VaribleDeclaration); !ok || a.isGlobal(decl) {
+		return
+	}
+
+	for i := len(a.closures) - 1; i >= 0; i-- {
+		c := a.closures[i]
+		if a.declaredWithin(name.Value(), c.scope) {
+			return
+		}
+
+		if !captured(c.lambda, name.Value()) {
+			c.lambda.Captures = append(c.lambda.Captures, &ast.ArgumentDeclaration{
+				Name: &ast.IdentExpression{Value: name},
+				Type: a.typ(decl),
+			})
+		}
+	}
+}
+
+// declaredWithin returns true if the name resolves to a declaration in the
+// scope or one of the scopes nested inside it
+func (a *Analysis) declaredWithin(name string, scope *ast.Scope) bool {
+	for s := a.currentBlock.Scope; s != nil; s = s.Exit() {
+		if s.LookupLocal(name) != nil {
+			return true
+		}
+		if s == scope {
+			return false
+		}
+	}
+
+	return false
+}
+
+func captured(lambda *ast.LambdaExpression, name string) bool {
+	for _, capture := range lambda.Captures {
+		if capture.Name.Value.Value() == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,132 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+)
+
+// methods is implemented by the types methods can be called on
+type methods interface {
+	Method(name string) (*types.Method, int)
+}
+
+// declareMethod adds the method to the method set of its receivers type
+func (a *Analysis) declareMethod(node *ast.FunctionDeclaration) {
+	name := node.Name.Value.Value()
+	typ := node.Receiver.Type
+
+	named, ok := typ.(interface {
+		AddMethod(method *types.Method) bool
+	})
+	if _, isInterface := types.Underlying(typ).(*types.Interface); isInterface || !ok {
+		panic(a.newError(node, fmt.Sprintf("cannot declare method %q on %s", name, typ)))
+	}
+
+	if len(node.TypeParameters) > 0 {
+		panic(a.newError(node, fmt.Sprintf("method %s.%s cannot have type parameters", typ, name)))
+	}
+
+	method := &types.Method{
+		Name: name,
+		Type: a.typ(node).(*types.Function),
+	}
+	if !named.AddMethod(method) {
+		panic(a.newError(node, fmt.Sprintf("method %s.%s redeclared", typ, name)))
+	}
+}
+
+// methodDcl returns the declaration of the method on the named type
+func (a *Analysis) methodDcl(typ types.Type, name string) *ast.FunctionDeclaration {
+	for _, f := range a.root.Functions {
+		if f.Receiver != nil && f.Receiver.Type == typ && f.Name.Value.Value() == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// method returns the method the selector names on a value of the type
+func (a *Analysis) method(node *ast.SelectorExpression, typ types.Type) *types.Method {
+	name := node.Selection.Value.Value()
+	if named, ok := typ.(methods); ok {
+		if method, _ := named.Method(name); method != nil {
+			return method
+		}
+	}
+
+	panic(a.newError(node, fmt.Sprintf("%s has no method %q", typ, name)))
+}
+
+// methodCallExp calls the method named by the selector with the value it
+// selects from as the receiver
+func (a *Analysis) methodCallExp(node *ast.SelectorExpression, arguments *ast.ParenLiteralExpression) ast.Expression {
+	log.Println("Method call")
+
+	receiver := a.expression(node.Expression)
+	typ := a.typ(receiver)
+	method := a.method(node, typ)
+
+	if a.pure() {
+		a.pureMethodCall(node, typ, method)
+	}
+
+	argTypes := method.Type.Arguments()
+	if len(arguments.Elements) != len(argTypes) {
+		panic(a.newError(node, fmt.Sprintf("method %s.%s expects %d arguments, got %d",
+			typ, method.Name, len(argTypes), len(arguments.Elements))))
+	}
+
+	newArguments := &ast.ParenLiteralExpression{
+		LeftParen:  arguments.LeftParen,
+		Elements:   make([]ast.Expression, len(arguments.Elements)),
+		RightParen: arguments.RightParen,
+	}
+	for i, arg := range arguments.Elements {
+		newArguments.Elements[i] = a.convert(a.expression(arg), argTypes[i])
+	}
+
+	return &ast.MethodCallExpression{
+		Receiver:  receiver,
+		Selector:  node,
+		Arguments: newArguments,
+		Type:      typ,
+		Method:    method,
+	}
+}
+
+// implement converts the value to the interface, the type of the value must
+// have every method of the interface
+func (a *Analysis) implement(node ast.Expression, typ types.Type, iface *types.Interface) ast.Expression {
+	if _, ok := typ.(*types.Interface); ok {
+		panic(a.newError(node, fmt.Sprintf("cannot use %v as %v", typ, iface)))
+	}
+
+	if missing, ok := types.Implements(typ, iface); !ok {
+		panic(a.newError(node, fmt.Sprintf("%s does not implement %s (missing method %s)",
+			typ, iface, missing)))
+	}
+
+	// Each type needs one vtable for each interface it is used as
+	implemented := false
+	for _, implementation := range a.root.Implementations {
+		if sameType(implementation.Type, typ) && implementation.Interface == iface {
+			implemented = true
+		}
+	}
+	if !implemented {
+		a.root.Implementations = append(a.root.Implementations, &ast.Implementation{
+			Type:      typ,
+			Interface: iface,
+		})
+	}
+
+	return &ast.InterfaceExpression{
+		Expression: node,
+		Named:      typ,
+		Type:       iface,
+	}
+}
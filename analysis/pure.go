@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+)
+
+// pure returns true if the procedure being analysed must not have side effects
+func (a *Analysis) pure() bool {
+	return a.currentFunction != nil && a.currentFunction.Pure
+}
+
+// impure reports a side effect in a pure function at the node
+func (a *Analysis) impure(node ast.Node, message string) {
+	panic(a.newError(node, fmt.Sprintf("func %q %s",
+		a.currentFunction.Name.Value.Value(), message)))
+}
+
+// lookup returns the declaration the name refers to in the current scope,
+// outside of any scope names resolve in the universe
+func (a *Analysis) lookup(name string) ast.Node {
+	if a.currentBlock == nil || a.currentBlock.Scope == nil {
+		return ast.Universe.Lookup(name)
+	}
+
+	return a.currentBlock.Scope.Lookup(name)
+}
+
+// isGlobal returns true if the declaration is a package level varible
+func (a *Analysis) isGlobal(node ast.Node) bool {
+	for _, global := range a.root.Globals {
+		if node == global {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pureAssignment checks an assignment in a pure function only writes to
+// local varibles
+func (a *Analysis) pureAssignment(node ast.Statement, target ast.Expression) {
+	switch target := target.(type) {
+	case *ast.IdentExpression:
+		if a.isGlobal(a.lookup(target.Value.Value())) {
+			a.impure(node, fmt.Sprintf("assigns to global %q", target.Value.Value()))
+		}
+
+	case *ast.ParenLiteralExpression:
+		for _, element := range target.Elements {
+			a.pureAssignment(node, element)
+		}
+
+	case *ast.IndexExpression:
+		switch a.typ(target.Expression).(type) {
+		case *types.Pointer, *types.Slice:
+			a.impure(node, "writes through a pointer")
+		}
+		a.pureAssignment(node, target.Expression)
+
+	case *ast.UnaryExpression:
+		if target.Operator.Type() == lexer.MUL {
+			a.impure(node, "writes through a pointer")
+		}
+
+	case *ast.SelectorExpression:
+		a.pureAssignment(node, target.Expression)
+	}
+}
+
+// pureCall checks a call in a pure function is to another pure function
+func (a *Analysis) pureCall(node *ast.CallExpression) {
+	if name, ok := a.builtin(node); ok {
+		if allocators[name] {
+			a.impure(node, fmt.Sprintf("calls builtin %q", name))
+		}
+		return
+	}
+
+	a.pureCallee(node, node.Function)
+}
+
+// pureCallee checks the function called, or each function composed into it,
+// is pure
+func (a *Analysis) pureCallee(node *ast.CallExpression, callee ast.Expression) {
+	switch callee := callee.(type) {
+	case *ast.BinaryExpression:
+		a.pureCallee(node, callee.Left)
+		a.pureCallee(node, callee.Right)
+
+	case *ast.CompositionExpression:
+		a.pureCallee(node, callee.Left)
+		a.pureCallee(node, callee.Right)
+
+	case *ast.LambdaExpression:
+		a.impure(node, "calls a lambda")
+
+	case *ast.IdentExpression:
+		var f *ast.FunctionDeclaration
+		switch decl := a.lookup(callee.Value.Value()).(type) {
+		case *ast.FunctionDeclaration:
+			f = decl
+		case *ast.VaribleDeclaration:
+			a.impure(node, fmt.Sprintf("calls function value %q", callee.Value.Value()))
+		default:
+			return
+		}
+
+		if !f.Pure {
+			a.impure(node, fmt.Sprintf("calls impure proc %q", callee.Value.Value()))
+		}
+
+		// The callee may read memory, only recursive calls are known not to
+		if f != a.currentFunction {
+			a.readsMemory = true
+		}
+	}
+}
+
+// pureMethodCall checks the method called is pure, methods called through an
+// interface could be any implementation so are impure
+func (a *Analysis) pureMethodCall(node *ast.SelectorExpression, typ types.Type, method *types.Method) {
+	if _, ok := typ.(*types.Interface); ok {
+		a.impure(node, fmt.Sprintf("calls interface method %s.%s", typ, method.Name))
+	}
+
+	f := a.methodDcl(typ, method.Name)
+	if !f.Pure {
+		a.impure(node, fmt.Sprintf("calls impure method %s.%s", typ, method.Name))
+	}
+
+	if f != a.currentFunction {
+		a.readsMemory = true
+	}
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+)
+
+// field returns the struct and index of the field the selector names on a
+// value of the type, the index is -1 if the type has no such field
+func field(node *ast.SelectorExpression, typ types.Type) (*types.Struct, int) {
+	structure, ok := typ.(*types.Struct)
+	if !ok {
+		return nil, -1
+	}
+
+	_, index := structure.Field(node.Selection.Value.Value())
+	return structure, index
+}
+
+// selectorExp resolves a selector that is not called, either a union variant
+// with no fields or a field of a struct
+func (a *Analysis) selectorExp(node *ast.SelectorExpression) ast.Expression {
+	if a.unionType(node.Expression) != nil {
+		return a.variantExp(node, nil)
+	}
+
+	log.Println("Field")
+
+	expression := a.expression(node.Expression)
+	typ := a.typ(expression)
+	name := node.Selection.Value.Value()
+
+	structure, index := field(node, typ)
+	if index < 0 {
+		if named, ok := typ.(methods); ok {
+			if method, _ := named.Method(name); method != nil {
+				panic(a.newError(node, fmt.Sprintf("method %s.%s must be called", typ, name)))
+			}
+		}
+		panic(a.newError(node, fmt.Sprintf("%s has no field or method %q", typ, name)))
+	}
+
+	return &ast.FieldExpression{
+		Selector:   node,
+		Expression: expression,
+		Struct:     structure,
+		Index:      index,
+		Type:       structure.Types()[index],
+	}
+}
+
+// structLiteralExp converts the value of each field to the type of the field
+func (a *Analysis) structLiteralExp(node *ast.BraceLiteralExpression, structure *types.Struct) ast.Expression {
+	fields := structure.Types()
+	if len(node.Elements) != len(fields) {
+		panic(a.newError(node, fmt.Sprintf("%s has %d fields, got %d values",
+			structure, len(fields), len(node.Elements))))
+	}
+
+	newBraceLiteralExp := &ast.BraceLiteralExpression{
+		Type:       structure,
+		LeftBrace:  node.LeftBrace,
+		Elements:   make([]ast.Expression, len(node.Elements)),
+		RightBrace: node.RightBrace,
+	}
+	for i, element := range node.Elements {
+		newBraceLiteralExp.Elements[i] = a.convert(a.expression(element), fields[i])
+	}
+
+	return newBraceLiteralExp
+}
@@ -0,0 +1,234 @@
+package analysis
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+)
+
+// unionType returns the union the expression names, or nil if it doesnt name one
+func (a *Analysis) unionType(node ast.Expression) *types.Union {
+	ident, ok := node.(*ast.IdentExpression)
+	if !ok {
+		return nil
+	}
+
+	dcl, ok := a.lookup(ident.Value.Value()).(*ast.TypeDeclaration)
+	if !ok {
+		return nil
+	}
+
+	union, _ := dcl.Value.(*types.Union)
+	return union
+}
+
+// variant returns the union and tag of the variant the selector names
+func (a *Analysis) variant(node *ast.SelectorExpression) (*types.Union, int) {
+	name := node.Selection.Value.Value()
+
+	union := a.unionType(node.Expression)
+	if union == nil {
+		panic(a.newError(node, fmt.Sprintf("method %q must be called", name)))
+	}
+
+	if _, tag := union.Variant(name); tag >= 0 {
+		return union, tag
+	}
+	panic(a.newError(node, fmt.Sprintf("%s has no variant %q", union, name)))
+}
+
+// variantExp creates a union value holding the variant named by the selector,
+// the arguments are nil for a variant that isnt called
+func (a *Analysis) variantExp(node *ast.SelectorExpression, arguments *ast.ParenLiteralExpression) ast.Expression {
+	log.Println("Variant")
+
+	union, tag := a.variant(node)
+	variant := union.Variants()[tag]
+
+	count := 0
+	if arguments != nil {
+		count = len(arguments.Elements)
+	}
+	if count != len(variant.Types) {
+		panic(a.newError(node, fmt.Sprintf("variant %s.%s has %d fields, got %d values",
+			union, variant.Name, len(variant.Types), count)))
+	}
+
+	newVariantExp := &ast.VariantExpression{
+		Selector: node,
+		Type:     union,
+		Tag:      tag,
+	}
+
+	if arguments != nil {
+		newVariantExp.Arguments = &ast.ParenLiteralExpression{
+			LeftParen:  arguments.LeftParen,
+			Elements:   make([]ast.Expression, count),
+			RightParen: arguments.RightParen,
+		}
+		for i, arg := range arguments.Elements {
+			newVariantExp.Arguments.Elements[i] = a.convert(a.expression(arg), variant.Types[i])
+		}
+	}
+
+	return newVariantExp
+}
+
+// matched analyses the value of a match, which must be a union
+func (a *Analysis) matched(node ast.Expression) (ast.Expression, *types.Union) {
+	value := a.expression(node)
+	union, ok := a.typ(value).(*types.Union)
+	if !ok {
+		panic(a.newError(node, fmt.Sprintf("cannot match on %s, only unions can be matched", a.typ(value))))
+	}
+
+	return value, union
+}
+
+// matchClause checks the variant handled by the clause and declares its
+// bindings, the body is analysed by the caller
+func (a *Analysis) matchClause(node *ast.MatchClause, union *types.Union) *ast.MatchClause {
+	newClause := &ast.MatchClause{
+		Case:     node.Case,
+		Name:     node.Name,
+		Bindings: node.Bindings,
+		Colon:    node.Colon,
+		Tag:      -1,
+	}
+
+	if node.IsDefault() {
+		return newClause
+	}
+
+	name := node.Name.Value.Value()
+	variant, tag := union.Variant(name)
+	if variant == nil {
+		panic(a.newError(node.Name, fmt.Sprintf("%s has no variant %q", union, name)))
+	}
+	newClause.Variant = variant
+	newClause.Tag = tag
+
+	// Bindings are optional, but must bind every field if given
+	if len(node.Bindings) != 0 && len(node.Bindings) != len(variant.Types) {
+		panic(a.newError(node, fmt.Sprintf("case %s binds %d of %d fields",
+			name, len(node.Bindings), len(variant.Types))))
+	}
+
+	for i, binding := range node.Bindings {
+		node.Body.Scope.Replace(binding.Value.Value(), &ast.VaribleDeclaration{
+			Name: binding,
+			Type: variant.Types[i],
+		})
+	}
+
+	return newClause
+}
+
+// exhaustive checks each variant of the union is handled by exactly one
+// clause, unless there is a default clause
+func (a *Analysis) exhaustive(node ast.Node, union *types.Union, clauses []*ast.MatchClause) {
+	handled := make(map[int]bool)
+	hasDefault := false
+
+	for _, clause := range clauses {
+		if clause.IsDefault() {
+			if hasDefault {
+				panic(a.newError(clause, "multiple defaults in match"))
+			}
+			hasDefault = true
+			continue
+		}
+
+		if handled[clause.Tag] {
+			panic(a.newError(clause, fmt.Sprintf("duplicate case %s in match", clause.Variant.Name)))
+		}
+		handled[clause.Tag] = true
+	}
+
+	if hasDefault {
+		return
+	}
+
+	missing := []string{}
+	for tag, variant := range union.Variants() {
+		if !handled[tag] {
+			missing = append(missing, variant.Name)
+		}
+	}
+	if len(missing) > 0 {
+		panic(a.newError(node, fmt.Sprintf("match on %s does not handle %s",
+			union, strings.Join(missing, ", "))))
+	}
+}
+
+func (a *Analysis) matchSmt(node *ast.MatchStatement) ast.Statement {
+	log.Println("Match")
+
+	newMatchSmt := &ast.MatchStatement{
+		Match:      node.Match,
+		LeftBrace:  node.LeftBrace,
+		Clauses:    make([]*ast.MatchClause, len(node.Clauses)),
+		RightBrace: node.RightBrace,
+	}
+
+	newMatchSmt.Value, newMatchSmt.Union = a.matched(node.Value)
+	union := newMatchSmt.Union
+
+	for i, clause := range node.Clauses {
+		newClause := a.matchClause(clause, union)
+		newClause.Body = a.blockSmt(clause.Body).(*ast.BlockStatement)
+		newMatchSmt.Clauses[i] = newClause
+	}
+	a.exhaustive(node, union, newMatchSmt.Clauses)
+
+	return newMatchSmt
+}
+
+// matchExp analyses a match expression, the result of each clause is
+// converted to the type of the first result that isnt a constant
+func (a *Analysis) matchExp(node *ast.MatchExpression) ast.Expression {
+	log.Println("Match expression")
+
+	newMatchExp := &ast.MatchExpression{
+		Match:      node.Match,
+		LeftBrace:  node.LeftBrace,
+		Clauses:    make([]*ast.MatchClause, len(node.Clauses)),
+		RightBrace: node.RightBrace,
+	}
+
+	newMatchExp.Value, newMatchExp.Union = a.matched(node.Value)
+	union := newMatchExp.Union
+
+	if len(node.Clauses) == 0 {
+		panic(a.newError(node, "match expression has no clauses"))
+	}
+
+	block := a.currentBlock
+	for i, clause := range node.Clauses {
+		newClause := a.matchClause(clause, union)
+		a.currentBlock = clause.Body
+		newClause.Result = a.expression(clause.Result)
+		newClause.Body = &ast.BlockStatement{}
+
+		if _, constant := a.evaluate(newClause.Result); newMatchExp.Type == nil && !constant {
+			newMatchExp.Type = a.typ(newClause.Result)
+		}
+		a.currentBlock = block
+		newMatchExp.Clauses[i] = newClause
+	}
+	a.exhaustive(node, union, newMatchExp.Clauses)
+
+	if newMatchExp.Type == nil {
+		newMatchExp.Type = a.typ(newMatchExp.Clauses[0].Result)
+	}
+	for i, clause := range newMatchExp.Clauses {
+		a.currentBlock = node.Clauses[i].Body
+		clause.Result = a.convert(clause.Result, newMatchExp.Type)
+	}
+	a.currentBlock = block
+
+	return newMatchExp
+}
@@ -1,6 +1,9 @@
 package ast
 
-import "github.com/bongo227/Furlang/lexer"
+import (
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+)
 
 type Node interface {
 	// First returns the first token beloning to the node
@@ -12,4 +15,30 @@ type Node interface {
 type Ast struct {
 	Scope     *Scope
 	Functions []*FunctionDeclaration
+
+	// Globals are the package level varible and constant declarations in
+	// source order
+	Globals []Declare
+
+	Types []*TypeDeclaration
+
+	// Lengths are the array types with a constant expression as their length
+	Lengths []*ArrayLength
+
+	// Implementations are the types analysis found converted to an interface,
+	// each needs a vtable
+	Implementations []*Implementation
+}
+
+// ArrayLength is the constant expression an array type was declared with,
+// analysis sets the length of the array to its value
+type ArrayLength struct {
+	Array  *types.Array
+	Length Expression
+}
+
+// Implementation is a named type used as an interface
+type Implementation struct {
+	Type      types.Type
+	Interface *types.Interface
 }
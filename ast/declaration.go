@@ -18,6 +18,21 @@ type FunctionDeclaration struct {
 	Arguments   []*ArgumentDeclaration
 	Return      types.Type
 	Body        *BlockStatement
+
+	// Pure is true for func declarations, which analysis checks have no side
+	// effects. ReadOnly is set by analysis if a pure function reads memory
+	// through a pointer or global.
+	Pure     bool
+	ReadOnly bool
+
+	// TypeParameters are declared by generic procedures, analysis adds an
+	// instance with the TypeArguments set for each set of types it is called with
+	TypeParameters []*types.Parameter
+	TypeArguments  []types.Type
+	Instances      []*FunctionDeclaration
+
+	// Receiver is the value a method is called on, nil for procedures
+	Receiver *ArgumentDeclaration
 }
 
 func (e *FunctionDeclaration) First() lexer.Token { return e.Name.First() }
@@ -44,3 +59,42 @@ type VaribleDeclaration struct {
 func (e *VaribleDeclaration) First() lexer.Token { return e.Name.First() }
 func (e *VaribleDeclaration) Last() lexer.Token  { return e.Value.Last() }
 func (e *VaribleDeclaration) declareNode()       {}
+
+// ConstantDeclaration is a declare node in the form: const type ident = expression
+type ConstantDeclaration struct {
+	Const lexer.Token
+	Type  types.Type
+	Name  *IdentExpression
+	Value Expression
+}
+
+func (e *ConstantDeclaration) First() lexer.Token { return e.Const }
+func (e *ConstantDeclaration) Last() lexer.Token  { return e.Value.Last() }
+func (e *ConstantDeclaration) declareNode()       {}
+
+// TupleDeclaration is a declare node in the form: ident, ident, ... := expression
+type TupleDeclaration struct {
+	Declarations []*VaribleDeclaration
+	Define       lexer.Token
+	Value        Expression
+}
+
+func (e *TupleDeclaration) First() lexer.Token { return e.Declarations[0].First() }
+func (e *TupleDeclaration) Last() lexer.Token  { return e.Value.Last() }
+func (e *TupleDeclaration) declareNode()       {}
+
+// TypeDeclaration is a declare node in the form: type ident enum { ident(type ident, ...), ... }
+// or type ident interface { ident :: type ident, ... -> type; ... }
+type TypeDeclaration struct {
+	Type  lexer.Token
+	Name  *IdentExpression
+	Value types.Type
+
+	// Alias is true for declarations in the form: type ident = type, the name
+	// refers to the same type instead of a new one
+	Alias bool
+}
+
+func (e *TypeDeclaration) First() lexer.Token { return e.Type }
+func (e *TypeDeclaration) Last() lexer.Token  { return e.Name.Last() }
+func (e *TypeDeclaration) declareNode()       {}
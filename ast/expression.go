@@ -93,6 +93,14 @@ func (e *SliceExpression) expressionNode()    {}
 type CallExpression struct {
 	Function  Expression
 	Arguments *ParenLiteralExpression
+
+	// TypeArguments are infered by analysis for calls to generic procedures
+	TypeArguments []types.Type
+
+	// Type is the signature of the procedure called and Builtin the builtin
+	// procedure called instead, set by analysis
+	Type    *types.Function
+	Builtin *Builtin
 }
 
 func (e *CallExpression) First() lexer.Token { return e.Function.First() }
@@ -123,6 +131,34 @@ func (e *BinaryExpression) First() lexer.Token { return e.Left.First() }
 func (e *BinaryExpression) Last() lexer.Token  { return e.Right.Last() }
 func (e *BinaryExpression) expressionNode()    {}
 
+// CompositionExpression is a function built from two functions in the form:
+// expression >> expression, the result of the left is passed to the right
+type CompositionExpression struct {
+	Left     Expression
+	Operator lexer.Token
+	Right    Expression
+	Type     *types.Function
+}
+
+func (e *CompositionExpression) First() lexer.Token { return e.Left.First() }
+func (e *CompositionExpression) Last() lexer.Token  { return e.Right.Last() }
+func (e *CompositionExpression) expressionNode()    {}
+
+// LambdaExpression is an anonymous procedure in the form:
+// proc :: arguments -> type block
+type LambdaExpression struct {
+	Proc     lexer.Token
+	Function *FunctionDeclaration
+
+	// Captures are the varibles of enclosing procedures used by the body,
+	// their values are copied into the environment when the lambda is evaluated
+	Captures []*ArgumentDeclaration
+}
+
+func (e *LambdaExpression) First() lexer.Token { return e.Proc }
+func (e *LambdaExpression) Last() lexer.Token  { return e.Function.Body.RightBrace }
+func (e *LambdaExpression) expressionNode()    {}
+
 // UnaryExpression is an expression in the form: operator expression
 type UnaryExpression struct {
 	Operator   lexer.Token
@@ -132,3 +168,90 @@ type UnaryExpression struct {
 func (e *UnaryExpression) First() lexer.Token { return e.Operator }
 func (e *UnaryExpression) Last() lexer.Token  { return e.Expression.Last() }
 func (e *UnaryExpression) expressionNode()    {}
+
+// SelectorExpression is an expression in the form: expression.ident
+type SelectorExpression struct {
+	Expression Expression
+	Period     lexer.Token
+	Selection  *IdentExpression
+}
+
+func (e *SelectorExpression) First() lexer.Token { return e.Expression.First() }
+func (e *SelectorExpression) Last() lexer.Token  { return e.Selection.Last() }
+func (e *SelectorExpression) expressionNode()    {}
+
+// VariantExpression is a union value created by analysis from a selector in the
+// form: type.variant(expression, ...), variants with no fields have no arguments
+type VariantExpression struct {
+	Selector  *SelectorExpression
+	Arguments *ParenLiteralExpression
+	Type      *types.Union
+	Tag       int
+}
+
+func (e *VariantExpression) First() lexer.Token { return e.Selector.First() }
+func (e *VariantExpression) Last() lexer.Token {
+	if e.Arguments == nil {
+		return e.Selector.Last()
+	}
+	return e.Arguments.Last()
+}
+func (e *VariantExpression) expressionNode() {}
+
+// MatchExpression is an expression in the form:
+// match expression { case ident(ident, ...): expression; ... }
+// the value of the clause handling the variant held by the union
+type MatchExpression struct {
+	Match      lexer.Token
+	Value      Expression
+	LeftBrace  lexer.Token
+	Clauses    []*MatchClause
+	RightBrace lexer.Token
+	Union      *types.Union
+	Type       types.Type
+}
+
+func (e *MatchExpression) First() lexer.Token { return e.Match }
+func (e *MatchExpression) Last() lexer.Token  { return e.RightBrace }
+func (e *MatchExpression) expressionNode()    {}
+
+// FieldExpression is a field of a struct value created by analysis from a
+// selector in the form: expression.ident
+type FieldExpression struct {
+	Selector   *SelectorExpression
+	Expression Expression
+	Struct     *types.Struct
+	Index      int
+	Type       types.Type
+}
+
+func (e *FieldExpression) First() lexer.Token { return e.Selector.First() }
+func (e *FieldExpression) Last() lexer.Token  { return e.Selector.Last() }
+func (e *FieldExpression) expressionNode()    {}
+
+// MethodCallExpression is a call created by analysis from a selector in the
+// form: expression.ident(expression, ...), the method is called directly for a
+// named type and through the vtable for an interface
+type MethodCallExpression struct {
+	Receiver  Expression
+	Selector  *SelectorExpression
+	Arguments *ParenLiteralExpression
+	Type      types.Type
+	Method    *types.Method
+}
+
+func (e *MethodCallExpression) First() lexer.Token { return e.Selector.First() }
+func (e *MethodCallExpression) Last() lexer.Token  { return e.Arguments.Last() }
+func (e *MethodCallExpression) expressionNode()    {}
+
+// InterfaceExpression is a value of the named type converted to an interface,
+// created by analysis
+type InterfaceExpression struct {
+	Expression Expression
+	Named      types.Type
+	Type       *types.Interface
+}
+
+func (e *InterfaceExpression) First() lexer.Token { return e.Expression.First() }
+func (e *InterfaceExpression) Last() lexer.Token  { return e.Expression.Last() }
+func (e *InterfaceExpression) expressionNode()    {}
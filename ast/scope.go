@@ -38,6 +38,11 @@ func (s *Scope) Lookup(name string) Node {
 	return nil
 }
 
+// LookupLocal returns the node if it was declared in this scope
+func (s *Scope) LookupLocal(name string) Node {
+	return s.scope[name]
+}
+
 func (s *Scope) Replace(name string, node Node) bool {
 	currentScope := s
 	for currentScope != nil {
@@ -1,6 +1,9 @@
 package ast
 
-import "github.com/bongo227/Furlang/lexer"
+import (
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+)
 
 type Statement interface {
 	Node
@@ -26,15 +29,40 @@ func (e *AssignmentStatement) First() lexer.Token { return e.Left.First() }
 func (e *AssignmentStatement) Last() lexer.Token  { return e.Right.Last() }
 func (e *AssignmentStatement) statementNode()     {}
 
-// ReturnStatement is a statement in the form: return expression
+// ReturnStatement is a statement in the form: return expression || return
 type ReturnStatement struct {
 	Return lexer.Token
 	Result Expression
 }
 
 func (e *ReturnStatement) First() lexer.Token { return e.Return }
-func (e *ReturnStatement) Last() lexer.Token  { return e.Result.Last() }
-func (e *ReturnStatement) statementNode()     {}
+func (e *ReturnStatement) Last() lexer.Token {
+	if e.Result == nil {
+		return e.Return
+	}
+	return e.Result.Last()
+}
+func (e *ReturnStatement) statementNode() {}
+
+// ExpressionStatement is a statement in the form: expression
+type ExpressionStatement struct {
+	Expression Expression
+}
+
+func (e *ExpressionStatement) First() lexer.Token { return e.Expression.First() }
+func (e *ExpressionStatement) Last() lexer.Token  { return e.Expression.Last() }
+func (e *ExpressionStatement) statementNode()     {}
+
+// DeferStatement is a statement in the form: defer call(), the call runs when
+// the procedure returns
+type DeferStatement struct {
+	Defer lexer.Token
+	Call  *CallExpression
+}
+
+func (e *DeferStatement) First() lexer.Token { return e.Defer }
+func (e *DeferStatement) Last() lexer.Token  { return e.Call.Last() }
+func (e *DeferStatement) statementNode()     {}
 
 // BlockStatement is a statement in the form: {statement; statement; ...}
 type BlockStatement struct {
@@ -74,3 +102,95 @@ type ForStatement struct {
 func (e *ForStatement) First() lexer.Token { return e.For }
 func (e *ForStatement) Last() lexer.Token  { return e.Body.Last() }
 func (e *ForStatement) statementNode()     {}
+
+// SwitchStatement is a statement in the form: switch expression { case expression, ...: statement; ... }
+type SwitchStatement struct {
+	Switch     lexer.Token
+	Tag        Expression
+	LeftBrace  lexer.Token
+	Clauses    []*CaseClause
+	RightBrace lexer.Token
+}
+
+func (e *SwitchStatement) First() lexer.Token { return e.Switch }
+func (e *SwitchStatement) Last() lexer.Token  { return e.RightBrace }
+func (e *SwitchStatement) statementNode()     {}
+
+// CaseClause is a clause of a switch statement in the form: case expression, ...: statement; ...
+// a default clause has no expressions
+type CaseClause struct {
+	Case        lexer.Token
+	Expressions []Expression
+	Colon       lexer.Token
+	Body        *BlockStatement
+}
+
+func (e *CaseClause) First() lexer.Token { return e.Case }
+func (e *CaseClause) Last() lexer.Token  { return e.Body.Last() }
+func (e *CaseClause) statementNode()     {}
+
+// IsDefault returns true if the clause is the default clause
+func (e *CaseClause) IsDefault() bool { return e.Case.Type() == lexer.DEFAULT }
+
+// Fallthrough returns true if the last statement of the clause is a fallthrough
+func (e *CaseClause) Fallthrough() bool {
+	if len(e.Body.Statements) == 0 {
+		return false
+	}
+
+	_, ok := e.Body.Statements[len(e.Body.Statements)-1].(*FallthroughStatement)
+	return ok
+}
+
+// FallthroughStatement is a statement in the form: fallthrough
+type FallthroughStatement struct {
+	Fallthrough lexer.Token
+}
+
+func (e *FallthroughStatement) First() lexer.Token { return e.Fallthrough }
+func (e *FallthroughStatement) Last() lexer.Token  { return e.Fallthrough }
+func (e *FallthroughStatement) statementNode()     {}
+
+// MatchStatement is a statement in the form:
+// match expression { case ident(ident, ...): statement; ... }
+type MatchStatement struct {
+	Match      lexer.Token
+	Value      Expression
+	LeftBrace  lexer.Token
+	Clauses    []*MatchClause
+	RightBrace lexer.Token
+	Union      *types.Union
+}
+
+func (e *MatchStatement) First() lexer.Token { return e.Match }
+func (e *MatchStatement) Last() lexer.Token  { return e.RightBrace }
+func (e *MatchStatement) statementNode()     {}
+
+// MatchClause is a clause of a match in the form: case ident(ident, ...): statement; ...
+// the bindings are declared in the body with the fields of the variant, a
+// default clause has no variant. Clauses of a match expression have a result
+// instead of statements.
+type MatchClause struct {
+	Case     lexer.Token
+	Name     *IdentExpression
+	Bindings []*IdentExpression
+	Colon    lexer.Token
+	Body     *BlockStatement
+	Result   Expression
+
+	// Variant and Tag are set by analysis
+	Variant *types.Variant
+	Tag     int
+}
+
+func (e *MatchClause) First() lexer.Token { return e.Case }
+func (e *MatchClause) Last() lexer.Token {
+	if e.Result != nil {
+		return e.Result.Last()
+	}
+	return e.Body.Last()
+}
+func (e *MatchClause) statementNode() {}
+
+// IsDefault returns true if the clause is the default clause
+func (e *MatchClause) IsDefault() bool { return e.Case.Type() == lexer.DEFAULT }
@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+)
+
+// Builtin is a constant or procedure provided by the compiler
+type Builtin struct {
+	Name string
+
+	// Type and Value are the type and value of a predeclared constant,
+	// builtin procedures have neither
+	Type  types.Type
+	Value interface{}
+}
+
+func (b *Builtin) First() lexer.Token { return lexer.NewToken(lexer.IDENT, b.Name, 0, 0) }
+func (b *Builtin) Last() lexer.Token  { return b.First() }
+
+// Universe is the scope every package scope is nested in, it declares the
+// basic types, predeclared constants and builtin procedures
+var Universe = universe()
+
+func universe() *Scope {
+	scope := NewScope()
+
+	for _, name := range []string{"int", "i8", "i16", "i32", "i64", "rune", "float", "f32", "f64", "bool", "string"} {
+		scope.Insert(name, &TypeDeclaration{
+			Name:  &IdentExpression{Value: lexer.NewToken(lexer.IDENT, name, 0, 0)},
+			Value: types.GetType(name),
+		})
+	}
+
+	scope.Insert("true", &Builtin{Name: "true", Type: types.BasicBool, Value: true})
+	scope.Insert("false", &Builtin{Name: "false", Type: types.BasicBool, Value: false})
+
+	for _, name := range []string{"new", "make", "free", "len", "cap", "min", "max", "abs", "sizeof"} {
+		scope.Insert(name, &Builtin{Name: name})
+	}
+
+	return scope
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 
 	"log"
 
@@ -13,14 +14,32 @@ func init() {
 	log.SetFlags(log.Ltime | log.Lshortfile)
 }
 
+// usageError prints the problem with the command line flags followed by the
+// usage and exits
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	flag.Usage()
+	os.Exit(2)
+}
+
 func main() {
 	// Parse command line flags
 	outputTokens := flag.Bool("tokens", false, "Create a file with the tokens")
 	outputAst := flag.Bool("ast", false, "Create file with the abstract syntax tree and pretty print it out")
 	noCompile := flag.Bool("nocode", false, "Stop the compiler before it generates llvm ir")
+	allocator := flag.String("allocator", "malloc", "Runtime allocator used by new, make and free (malloc or bump)")
+	bounds := flag.String("bounds", "on", "Runtime bounds checks on array and slice indexes (on or off)")
+	overflow := flag.String("overflow", "wrap", "Integer overflow behaviour (wrap or trap)")
 	buildDirectory := flag.String("builddir", "build", "Directory any files create in the compile processes should be created")
 	flag.Parse()
 
+	if *bounds != "on" && *bounds != "off" {
+		usageError("invalid value %q for -bounds, must be on or off", *bounds)
+	}
+	if *overflow != "wrap" && *overflow != "trap" {
+		usageError("invalid value %q for -overflow, must be wrap or trap", *overflow)
+	}
+
 	path := flag.Arg(0)
 	log.Println(path)
 	comp, err := compiler.New(path)
@@ -31,6 +50,9 @@ func main() {
 	comp.OutputTokens = *outputTokens
 	comp.OutputAst = *outputAst
 	comp.NoCompile = *noCompile
+	comp.Allocator = *allocator
+	comp.NoBoundsCheck = *bounds == "off"
+	comp.OverflowTrap = *overflow == "trap"
 
 	if err = comp.Compile(*buildDirectory); err != nil {
 		fmt.Println(err)
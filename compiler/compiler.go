@@ -15,12 +15,22 @@ import (
 
 // Compiler hold infomation about the file to be compiled
 type Compiler struct {
+	path    string
 	program string
 
 	// Compiler optional flags
 	OutputTokens bool
 	OutputAst    bool
 	NoCompile    bool
+
+	// Allocator is the name of the runtime allocator, malloc is used if empty
+	Allocator string
+
+	// NoBoundsCheck removes runtime bounds checks from indexing
+	NoBoundsCheck bool
+
+	// OverflowTrap aborts on integer overflow instead of wrapping
+	OverflowTrap bool
 }
 
 // New creates a new compiler for the file at filePath
@@ -37,15 +47,28 @@ func New(filePath string) (*Compiler, error) {
 	program := string(data)
 
 	return &Compiler{
+		path:    filePath,
 		program: program,
 	}, nil
 }
 
 // Compile compiles the file and writes to the outPath
-func (c *Compiler) Compile(buildDirectory string) error {
+func (c *Compiler) Compile(buildDirectory string) (err error) {
 	// Start compiler timer
 	start := time.Now()
 
+	// Analysis panics with an error in the program, anything else is a bug in
+	// the compiler
+	defer func() {
+		if r := recover(); r != nil {
+			analysisErr, ok := r.(*analysis.Error)
+			if !ok {
+				panic(r)
+			}
+			err = analysisErr
+		}
+	}()
+
 	// Run lexer
 	l := lexer.NewLexer([]byte(c.program))
 	tokens, err := l.Lex()
@@ -91,6 +114,16 @@ func (c *Compiler) Compile(buildDirectory string) error {
 	// Compile ast to llvm
 	if !c.NoCompile {
 		ir := irgen.NewIrgen(ast)
+		ir.File = c.path
+		ir.BoundsCheck = !c.NoBoundsCheck
+		ir.OverflowTrap = c.OverflowTrap
+		if c.Allocator != "" {
+			allocator, ok := irgen.Allocators[c.Allocator]
+			if !ok {
+				return fmt.Errorf("unknown allocator %q", c.Allocator)
+			}
+			ir.Allocator = allocator
+		}
 		llvm := ir.Generate()
 
 		f, err := os.Create(buildDirectory + "/ben.ll")
@@ -0,0 +1,85 @@
+package irgen
+
+import (
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// Allocator is the runtime allocator heap memory is requested from. Programs
+// never call the allocator directly, new, make and free are lowered to calls
+// to fur_alloc and fur_free whose bodies the allocator generates.
+type Allocator interface {
+	// Alloc builds the body of fur_alloc, which takes a size in bytes and
+	// returns a pointer to zeroed memory
+	Alloc(module *goory.Module, function *goory.Function, size gooryvalues.Value)
+
+	// Free builds the body of fur_free, which takes a pointer returned by fur_alloc
+	Free(module *goory.Module, function *goory.Function, ptr gooryvalues.Value)
+}
+
+// Allocators are the allocators selectable by name
+var Allocators = map[string]Allocator{
+	"malloc": MallocAllocator{},
+	"bump":   &BumpAllocator{Size: 1 << 20},
+}
+
+// bytePointer is the type of untyped pointers passed to and from the allocator
+func bytePointer() gtypes.Type {
+	return gtypes.NewPointerType(goory.IntType(8))
+}
+
+// MallocAllocator forwards to the c standard library
+type MallocAllocator struct{}
+
+func (m MallocAllocator) Alloc(module *goory.Module, function *goory.Function, size gooryvalues.Value) {
+	m.allocate(module, function.Entry(), size)
+}
+
+// allocate returns zeroed memory of the size from calloc at the end of the block
+func (MallocAllocator) allocate(module *goory.Module, block *goory.Block, size gooryvalues.Value) {
+	calloc := module.NewDeclaration("calloc", bytePointer(), goory.IntType(64), goory.IntType(64))
+	block.Ret(block.Call(calloc, goory.Constant(goory.IntType(64), 1), size))
+}
+
+func (MallocAllocator) Free(module *goory.Module, function *goory.Function, ptr gooryvalues.Value) {
+	free := module.NewDeclaration("free", goory.VoidType(), bytePointer())
+
+	block := function.Entry()
+	block.Call(free, ptr)
+	block.RetVoid()
+}
+
+// BumpAllocator hands out memory from a fixed size arena, memory is never
+// reclaimed so free does nothing. Once the arena is exhausted allocations
+// fall back to calloc, which are never freed either.
+type BumpAllocator struct {
+	Size int
+}
+
+func (b *BumpAllocator) Alloc(module *goory.Module, function *goory.Function, size gooryvalues.Value) {
+	arena := module.NewGlobal("fur_arena", gtypes.NewArrayType(goory.IntType(8), b.Size))
+	offset := module.NewGlobal("fur_offset", goory.IntType(64))
+
+	block := function.Entry()
+	start := block.Load(offset)
+
+	// Keep every allocation 8 byte aligned
+	eight := goory.Constant(goory.IntType(64), 8)
+	rounded := block.Add(size, goory.Constant(goory.IntType(64), 7))
+	rounded = block.Mul(block.Div(rounded, eight), eight)
+	end := block.Add(start, rounded)
+
+	bump, fallback := function.AddBlock(), function.AddBlock()
+	block.CondBr(block.Icmp(goory.IntSle, end, goory.Constant(goory.IntType(64), b.Size)), bump, fallback)
+
+	bump.Store(offset, end)
+	bump.Ret(bump.Getelementptr(goory.IntType(8), arena,
+		goory.Constant(goory.IntType(64), 0), start))
+
+	MallocAllocator{}.allocate(module, fallback, size)
+}
+
+func (b *BumpAllocator) Free(module *goory.Module, function *goory.Function, ptr gooryvalues.Value) {
+	function.Entry().RetVoid()
+}
@@ -0,0 +1,27 @@
+package irgen
+
+import (
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/goory"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// boundsCheck branches to the bounds panic routine unless 0 <= index < length
+func (g *Irgen) boundsCheck(node *ast.IndexExpression, index, length gooryvalues.Value) {
+	if index.Type() != goory.IntType(64) {
+		index = g.parentBlock.Cast(index, goory.IntType(64))
+	}
+
+	// Negative indexes wrap to large unsigned values so one compare covers both ends
+	outOfRange := g.parentBlock.Icmp(goory.IntUge, index, length)
+
+	routine := g.panicFunction("fur_bounds_panic",
+		"index out of range [%ld] with length %ld", "index", "length")
+	g.check(outOfRange, node.Index.First(), routine, index, length)
+}
+
+// inBounds returns true if the index is a constant known to be in range
+func inBounds(index ast.Expression, length int) bool {
+	value, ok := intConstant(index)
+	return ok && value >= 0 && value < int64(length)
+}
@@ -0,0 +1,116 @@
+package irgen
+
+import (
+	"log"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// builtinExp lowers a call to a builtin procedure inline
+func (g *Irgen) builtinExp(name string, node *ast.CallExpression) gooryvalues.Value {
+	arguments := node.Arguments.Elements
+
+	switch name {
+	case "new":
+		typ := arguments[0].(*ast.TypeExpression).Type
+		size := goory.Constant(goory.IntType(64), types.Sizeof(typ))
+		ptr := g.parentBlock.Call(g.allocFunction(), size)
+		return g.parentBlock.Cast(ptr, gtypes.NewPointerType(typ.Llvm()))
+
+	case "make":
+		slice := arguments[0].(*ast.TypeExpression).Type.(*types.Slice)
+		length := g.expression(arguments[1])
+		size := g.parentBlock.Mul(length,
+			goory.Constant(goory.IntType(64), types.Sizeof(slice.Type())))
+		ptr := g.parentBlock.Call(g.allocFunction(), size)
+		data := g.parentBlock.Cast(ptr, gtypes.NewPointerType(slice.Type().Llvm()))
+		return g.aggregate(data, length, length)
+
+	case "free":
+		ptr := g.freed(node, g.expression(arguments[0]))
+		g.parentBlock.Call(g.freeFunction(), ptr)
+		return nil
+
+	case "len", "cap":
+		// Analysis replaces the length of an array with a constant, slices are
+		// { data, length, capacity }
+		index := 1
+		if name == "cap" {
+			index = 2
+		}
+		return g.parentBlock.Extractvalue(g.expression(arguments[0]), index)
+
+	case "min", "max":
+		result := g.expression(arguments[0])
+		for _, arg := range arguments[1:] {
+			value := g.expression(arg)
+			if name == "min" {
+				result = g.parentBlock.Select(g.less(value, result), value, result)
+			} else {
+				result = g.parentBlock.Select(g.less(result, value), value, result)
+			}
+		}
+		return result
+
+	case "abs":
+		value := g.expression(arguments[0])
+		switch value.Type() {
+		case goory.FloatType(), goory.DoubleType():
+			zero := goory.Constant(value.Type(), 0.0)
+			return g.parentBlock.Select(g.less(value, zero), g.parentBlock.Fsub(zero, value), value)
+		default:
+			// The negation is checked before the select, so the smallest
+			// integer traps rather than staying negative
+			negated := g.negate(value, node.First())
+			return g.parentBlock.Select(g.less(value, goory.Constant(value.Type(), 0)), negated, value)
+		}
+	}
+
+	log.Fatalf("Unknown builtin %q", name)
+	return nil
+}
+
+// freed returns the memory a call to free releases, function values release
+// their environment
+func (g *Irgen) freed(node *ast.CallExpression, value gooryvalues.Value) gooryvalues.Value {
+	if _, ok := types.Underlying(node.Type.Arguments()[0]).(*types.Function); ok {
+		return g.parentBlock.Extractvalue(value, 1)
+	}
+
+	return g.bytes(value)
+}
+
+// bytes converts a pointer or slice to the untyped pointer the allocator expects
+func (g *Irgen) bytes(ptr gooryvalues.Value) gooryvalues.Value {
+	if _, ok := ptr.Type().(gtypes.StructType); ok {
+		ptr = g.parentBlock.Extractvalue(ptr, 0)
+	}
+
+	return g.parentBlock.Cast(ptr, bytePointer())
+}
+
+// allocFunction returns fur_alloc, generating it on first use
+func (g *Irgen) allocFunction() *goory.Function {
+	if g.alloc == nil {
+		g.alloc = g.module.NewFunction("fur_alloc", bytePointer())
+		size := g.alloc.AddArgument(goory.IntType(64), "size")
+		g.Allocator.Alloc(g.module, g.alloc, size)
+	}
+
+	return g.alloc
+}
+
+// freeFunction returns fur_free, generating it on first use
+func (g *Irgen) freeFunction() *goory.Function {
+	if g.free == nil {
+		g.free = g.module.NewFunction("fur_free", goory.VoidType())
+		ptr := g.free.AddArgument(bytePointer(), "ptr")
+		g.Allocator.Free(g.module, g.free, ptr)
+	}
+
+	return g.free
+}
@@ -0,0 +1,105 @@
+package irgen
+
+import (
+	"fmt"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// Arrays are never passed to or returned from llvm functions as aggregates.
+// The caller copies each array argument to memory and passes a pointer to the
+// copy, which the procedure can modify in place. Arrays are returned by
+// storing them through a pointer to memory the caller allocates, passed after
+// the environment and before the arguments.
+
+// parameterType returns the llvm type of a procedure parameter of the type
+func parameterType(typ types.Type) gtypes.Type {
+	if types.Indirect(typ) {
+		return gtypes.NewPointerType(typ.Llvm())
+	}
+
+	return typ.Llvm()
+}
+
+// parameters adds the result pointer, if the procedure returns an array, and
+// the arguments of the signature to the function
+func parameters(f *goory.Function, typ *types.Function) (result gooryvalues.Value, args []gooryvalues.Value) {
+	if types.Indirect(typ.Return()) {
+		result = f.AddArgument(parameterType(typ.Return()), "result")
+	}
+
+	for i, arg := range typ.Arguments() {
+		args = append(args, f.AddArgument(parameterType(arg), fmt.Sprintf("arg%d", i)))
+	}
+
+	return result, args
+}
+
+// indirectArguments returns true if the procedure takes an array argument
+func indirectArguments(node *ast.FunctionDeclaration) bool {
+	if node.Receiver != nil && types.Indirect(node.Receiver.Type) {
+		return true
+	}
+
+	for _, arg := range node.Arguments {
+		if types.Indirect(arg.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// call calls the function with the values of its arguments and returns the
+// result, env is passed first if it is not nil
+func (g *Irgen) call(function gooryvalues.Value, ret types.Type, env gooryvalues.Value, args ...gooryvalues.Value) gooryvalues.Value {
+	var hidden []gooryvalues.Value
+	if env != nil {
+		hidden = append(hidden, env)
+	}
+
+	var result gooryvalues.Value
+	if types.Indirect(ret) {
+		result = g.parentBlock.Alloca(ret.Llvm())
+		hidden = append(hidden, result)
+	}
+
+	for i, arg := range args {
+		if _, ok := arg.Type().(gtypes.ArrayType); ok {
+			args[i] = g.temporary(arg)
+		}
+	}
+
+	value := g.parentBlock.Call(function, append(hidden, args...)...)
+	if result != nil {
+		return g.parentBlock.Load(result)
+	}
+
+	return value
+}
+
+// forward calls the function with the parameters of the procedure the block
+// belongs to and returns its result, array pointers are passed on unchanged
+func forward(block *goory.Block, function gooryvalues.Value, ret types.Type, result gooryvalues.Value, args ...gooryvalues.Value) {
+	if result != nil {
+		args = append([]gooryvalues.Value{result}, args...)
+	}
+
+	value := block.Call(function, args...)
+	if ret == nil || result != nil {
+		block.RetVoid()
+	} else {
+		block.Ret(value)
+	}
+}
+
+// temporary copies the value to the stack and returns its address
+func (g *Irgen) temporary(value gooryvalues.Value) gooryvalues.Value {
+	alloc := g.parentBlock.Alloca(value.Type())
+	g.parentBlock.Store(alloc, value)
+	return alloc
+}
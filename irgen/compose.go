@@ -0,0 +1,97 @@
+package irgen
+
+import (
+	"log"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// direct returns the function called by the expression if it is known at
+// compile time, any other function value is called through its pointer
+func (g *Irgen) direct(node ast.Expression) (*goory.Function, bool) {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		funcName := node.Value.Value()
+		log.Printf("Function name: %q", funcName)
+
+		if _, ok := g.scope.GetVar(funcName); ok {
+			return nil, false
+		}
+		return g.scope.GetFunction(funcName)
+
+	case *ast.CompositionExpression:
+		return g.composition(node), true
+	}
+
+	return nil, false
+}
+
+// callee returns the function called by the expression
+func (g *Irgen) callee(node ast.Expression) *goory.Function {
+	function, ok := g.direct(node)
+	if !ok {
+		log.Fatalf("Cannot compose %T", node)
+	}
+
+	return function
+}
+
+// returns returns the return type of a function that can be composed
+func (g *Irgen) returns(node ast.Expression) types.Type {
+	if composition, ok := node.(*ast.CompositionExpression); ok {
+		return composition.Type.Return()
+	}
+
+	return g.signature(compositionName(node)).Return()
+}
+
+// compositionName returns a name for the composed function made from the
+// names of the functions it calls
+func compositionName(node ast.Expression) string {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		return node.Value.Value()
+	case *ast.CompositionExpression:
+		return compositionName(node.Left) + "." + compositionName(node.Right)
+	}
+
+	log.Fatalf("Cannot compose %T", node)
+	return ""
+}
+
+// composition returns a function that passes its arguments to the left
+// function and its result to the right, generating it on first use
+func (g *Irgen) composition(node *ast.CompositionExpression) *goory.Function {
+	name := "compose." + compositionName(node)
+	if f, ok := g.declarations[name]; ok {
+		return f
+	}
+
+	left := g.callee(node.Left)
+	right := g.callee(node.Right)
+
+	if g.declarations == nil {
+		g.declarations = make(map[string]*goory.Function)
+	}
+
+	f := g.module.NewFunction(name, returnType(node.Type.Return()))
+	g.declarations[name] = f
+
+	result, args := parameters(f, node.Type)
+	block := f.Entry()
+
+	// Arrays returned by the left function are passed to the right in place
+	var value gooryvalues.Value
+	if middle := g.returns(node.Left); types.Indirect(middle) {
+		value = block.Alloca(middle.Llvm())
+		block.Call(left, append([]gooryvalues.Value{value}, args...)...)
+	} else {
+		value = block.Call(left, args...)
+	}
+
+	forward(block, right, node.Type.Return(), result, value)
+	return f
+}
@@ -0,0 +1,181 @@
+package irgen
+
+import (
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+
+	instructions "github.com/bongo227/goory/instructions"
+)
+
+// cleanup holds the state of a procedure that contains defer statements.
+// Every return stores its result and branches to the exit block, which runs
+// each deferred call that was reached in reverse order before returning.
+type cleanup struct {
+	exit   *goory.Block
+	result *instructions.Alloca
+	defers []*deferred
+	lookup map[*ast.DeferStatement]*deferred
+}
+
+// deferred is a call registered by a defer statement. The flag records if the
+// statement was reached, the arguments are evaluated when it is.
+type deferred struct {
+	function  *goory.Function
+	flag      *instructions.Alloca
+	arguments []*instructions.Alloca
+
+	// result is the memory a deferred procedure that returns an array
+	// stores its discarded result in
+	result *instructions.Alloca
+
+	// free is true when the deferred call is the free builtin
+	free bool
+}
+
+// defers returns every defer statement in the statement in source order
+func defers(node ast.Statement) []*ast.DeferStatement {
+	switch node := node.(type) {
+	case *ast.DeferStatement:
+		return []*ast.DeferStatement{node}
+	case *ast.BlockStatement:
+		var found []*ast.DeferStatement
+		for _, smt := range node.Statements {
+			found = append(found, defers(smt)...)
+		}
+		return found
+	case *ast.IfStatment:
+		found := defers(node.Body)
+		if node.Else != nil {
+			found = append(found, defers(node.Else)...)
+		}
+		return found
+	case *ast.ForStatement:
+		return defers(node.Body)
+	case *ast.SwitchStatement:
+		var found []*ast.DeferStatement
+		for _, clause := range node.Clauses {
+			found = append(found, defers(clause.Body)...)
+		}
+		return found
+	case *ast.MatchStatement:
+		var found []*ast.DeferStatement
+		for _, clause := range node.Clauses {
+			found = append(found, defers(clause.Body)...)
+		}
+		return found
+	}
+
+	return nil
+}
+
+// newCleanup allocates the flags and argument slots for the defer statements
+// in the entry block so they dominate the exit block
+func (g *Irgen) newCleanup(node *ast.FunctionDeclaration, statements []*ast.DeferStatement) *cleanup {
+	c := &cleanup{
+		exit:   g.parentBlock.Function().AddBlock(),
+		lookup: make(map[*ast.DeferStatement]*deferred),
+	}
+
+	if node.Return != nil && !types.Indirect(node.Return) {
+		c.result = g.parentBlock.Alloca(node.Return.Llvm())
+	}
+
+	for _, smt := range statements {
+		d := &deferred{
+			flag: g.parentBlock.Alloca(goory.BoolType()),
+		}
+		g.parentBlock.Store(d.flag, goory.Constant(goory.BoolType(), false))
+
+		var argTypes []gtypes.Type
+		name := mangle(smt.Call.Function.(*ast.IdentExpression).Value.Value(), smt.Call.TypeArguments)
+		if function, ok := g.scope.GetFunction(name); ok {
+			d.function = function
+			for _, arg := range smt.Call.Type.Arguments() {
+				argTypes = append(argTypes, arg.Llvm())
+			}
+			if ret := smt.Call.Type.Return(); types.Indirect(ret) {
+				d.result = g.parentBlock.Alloca(ret.Llvm())
+			}
+		} else {
+			// free is the only builtin analysis allows to be deferred
+			d.free = true
+			argTypes = []gtypes.Type{bytePointer()}
+		}
+
+		for _, argType := range argTypes {
+			d.arguments = append(d.arguments, g.parentBlock.Alloca(argType))
+		}
+
+		c.defers = append(c.defers, d)
+		c.lookup[smt] = d
+	}
+
+	return c
+}
+
+// deferSmt evaluates the arguments of the deferred call and marks it to run
+func (g *Irgen) deferSmt(node *ast.DeferStatement) {
+	d := g.cleanup.lookup[node]
+
+	for i, arg := range node.Call.Arguments.Elements {
+		value := g.expression(arg)
+		if d.free {
+			value = g.freed(node.Call, value)
+		}
+		g.parentBlock.Store(d.arguments[i], value)
+	}
+
+	g.parentBlock.Store(d.flag, goory.Constant(goory.BoolType(), true))
+}
+
+// exit branches to the cleanup block, storing the result first
+func (g *Irgen) exit(result gooryvalues.Value) {
+	if result != nil {
+		g.parentBlock.Store(g.cleanup.result, result)
+	}
+	g.parentBlock.Br(g.cleanup.exit)
+}
+
+// runCleanup generates the exit block, running the deferred calls in reverse
+// order then returning
+func (g *Irgen) runCleanup() {
+	g.parentBlock = g.cleanup.exit
+
+	for i := len(g.cleanup.defers) - 1; i >= 0; i-- {
+		d := g.cleanup.defers[i]
+		run := g.parentBlock.Function().AddBlock()
+		next := g.parentBlock.Function().AddBlock()
+		g.parentBlock.CondBr(g.parentBlock.Load(d.flag), run, next)
+
+		var args []gooryvalues.Value
+		if d.result != nil {
+			args = append(args, d.result)
+		}
+		for _, slot := range d.arguments {
+			// The slot already holds the copy of an array argument
+			if _, ok := pointee(slot).(gtypes.ArrayType); ok {
+				args = append(args, slot)
+			} else {
+				args = append(args, run.Load(slot))
+			}
+		}
+
+		if d.free {
+			run.Call(g.freeFunction(), args...)
+		} else {
+			run.Call(d.function, args...)
+		}
+		run.Br(next)
+
+		g.parentBlock = next
+	}
+
+	if g.cleanup.result == nil {
+		g.parentBlock.RetVoid()
+	} else {
+		g.parentBlock.Ret(g.parentBlock.Load(g.cleanup.result))
+	}
+}
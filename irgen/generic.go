@@ -0,0 +1,47 @@
+package irgen
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+)
+
+// mangle returns the name of the instance of a generic procedure
+func mangle(name string, typeArguments []types.Type) string {
+	parts := []string{name}
+	for _, typ := range typeArguments {
+		parts = append(parts, strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		}, typ.String()))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// functionName returns the name of the procedure in the module
+func functionName(node *ast.FunctionDeclaration) string {
+	if node.Receiver != nil {
+		return methodName(node.Receiver.Type, node.Name.Value.Value())
+	}
+	return mangle(node.Name.Value.Value(), node.TypeArguments)
+}
+
+// procedures returns every procedure that needs generating, generic
+// procedures are replaced by their instances
+func (g *Irgen) procedures() []*ast.FunctionDeclaration {
+	var procedures []*ast.FunctionDeclaration
+	for _, f := range g.tree.Functions {
+		if len(f.TypeParameters) > 0 {
+			procedures = append(procedures, f.Instances...)
+			continue
+		}
+		procedures = append(procedures, f)
+	}
+
+	return procedures
+}
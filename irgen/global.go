@@ -0,0 +1,84 @@
+package irgen
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// globals creates a module global for every package level varible. Constant
+// values become the initializer, any other value is stored by fur_init.
+func (g *Irgen) globals() {
+	for _, dcl := range g.tree.Globals {
+		// Analysis substitutes the value of constants where they are used
+		decl, ok := dcl.(*ast.VaribleDeclaration)
+		if !ok {
+			continue
+		}
+
+		name := decl.Name.Value.Value()
+		log.Printf("Declaring global %q", name)
+
+		global := g.module.NewGlobal(name, decl.Type.Llvm())
+		g.scope.AddVar(name, global)
+
+		if value, ok := constantValue(decl.Value); ok {
+			global.SetInitializer(value)
+			continue
+		}
+
+		g.initialise()
+		if _, ok := decl.Type.(*types.Array); ok {
+			g.arraySmt(decl.Value, global)
+		} else {
+			g.parentBlock.Store(global, g.expression(decl.Value))
+		}
+	}
+}
+
+// initialise makes fur_init the current procedure, creating it on first use.
+// Generate terminates it once every global has been stored.
+func (g *Irgen) initialise() {
+	if g.init == nil {
+		g.init = g.module.NewFunction("fur_init", goory.VoidType())
+		g.parentBlock = g.init.Entry()
+	}
+}
+
+// constantValue returns the value of a folded constant expression, analysis
+// leaves these as a literal or a literal cast to the type of the constant
+func constantValue(node ast.Expression) (gooryvalues.Value, bool) {
+	var typ types.Type
+	if cast, ok := node.(*ast.CastExpression); ok {
+		typ = cast.Type
+		node = cast.Expression
+	}
+
+	literal, ok := node.(*ast.LiteralExpression)
+	if !ok {
+		return nil, false
+	}
+
+	switch literal.Value.Type() {
+	case lexer.INT:
+		if typ == nil {
+			typ = types.IntType(0)
+		}
+		value, err := strconv.ParseInt(literal.Value.Value(), 0, 64)
+		return goory.Constant(typ.Llvm(), value), err == nil
+
+	case lexer.FLOAT:
+		if typ == nil {
+			typ = types.FloatType(0)
+		}
+		value, err := strconv.ParseFloat(literal.Value.Value(), 64)
+		return goory.Constant(typ.Llvm(), value), err == nil
+	}
+
+	return nil, false
+}
@@ -0,0 +1,130 @@
+package irgen
+
+import (
+	"log"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// methodName returns the name of the method of the named type in the module
+func methodName(typ types.Type, name string) string {
+	return mangle(typ.String()+"."+name, nil)
+}
+
+// vtableName returns the name of the global holding the methods of the type
+// used as the interface
+func vtableName(typ types.Type, iface *types.Interface) string {
+	return mangle("vtable."+typ.String()+"."+iface.String(), nil)
+}
+
+// vtables creates a vtable global for every type converted to an interface,
+// the method pointers are stored by fur_init
+func (g *Irgen) vtables() {
+	for _, implementation := range g.tree.Implementations {
+		name := vtableName(implementation.Type, implementation.Interface)
+		log.Printf("Declaring vtable %q", name)
+
+		vtable := g.module.NewGlobal(name, implementation.Interface.Vtable())
+		g.scope.AddVar(name, vtable)
+
+		g.initialise()
+		for i, method := range implementation.Interface.Methods() {
+			thunk := g.thunk(implementation.Type, method)
+			ptr := g.parentBlock.Getelementptr(thunk.Type(), vtable,
+				goory.Constant(goory.IntType(32), 0),
+				goory.Constant(goory.IntType(32), i))
+			g.parentBlock.Store(ptr, thunk)
+		}
+	}
+}
+
+// thunk returns the procedure the vtable holds for the method, it loads the
+// value from the data pointer and calls the method with it
+func (g *Irgen) thunk(typ types.Type, method *types.Method) *goory.Function {
+	name := methodName(typ, method.Name)
+	f, ok := g.scope.GetFunction(name)
+	if !ok {
+		log.Fatalf("Method %q was not declared", name)
+	}
+
+	name += ".dynamic"
+	if thunk, ok := g.declarations[name]; ok {
+		return thunk
+	}
+	if g.declarations == nil {
+		g.declarations = make(map[string]*goory.Function)
+	}
+
+	thunk := g.module.NewFunction(name, returnType(method.Type.Return()))
+	g.declarations[name] = thunk
+
+	data := thunk.AddArgument(bytePointer(), "data")
+	result, args := parameters(thunk, method.Type)
+
+	block := thunk.Entry()
+	receiver := block.Load(block.Cast(data, gtypes.NewPointerType(typ.Llvm())))
+
+	// Array receivers are passed a copy like any other array argument
+	if types.Indirect(typ) {
+		alloc := block.Alloca(typ.Llvm())
+		block.Store(alloc, receiver)
+		receiver = alloc
+	}
+
+	forward(block, f, method.Type.Return(), result, append([]gooryvalues.Value{receiver}, args...)...)
+	return thunk
+}
+
+// interfaceExp copies the value to the heap and pairs it with the vtable of
+// its type
+func (g *Irgen) interfaceExp(node *ast.InterfaceExpression) gooryvalues.Value {
+	value := g.expression(node.Expression)
+
+	size := goory.Constant(goory.IntType(64), types.Sizeof(node.Named))
+	data := g.parentBlock.Call(g.allocFunction(), size)
+	g.parentBlock.Store(g.parentBlock.Cast(data, gtypes.NewPointerType(value.Type())), value)
+
+	name := vtableName(node.Named, node.Type)
+	vtable, ok := g.scope.GetVar(name)
+	if !ok {
+		log.Fatalf("Vtable %q was not declared", name)
+	}
+
+	return g.aggregate(data, vtable)
+}
+
+// methodCallExp calls a method of a named type directly, methods of an
+// interface are loaded from its vtable
+func (g *Irgen) methodCallExp(node *ast.MethodCallExpression) gooryvalues.Value {
+	receiver := g.expression(node.Receiver)
+
+	args := make([]gooryvalues.Value, len(node.Arguments.Elements))
+	for i, element := range node.Arguments.Elements {
+		args[i] = g.expression(element)
+	}
+
+	ret := node.Method.Type.Return()
+	iface, ok := node.Type.(*types.Interface)
+	if !ok {
+		name := methodName(node.Type, node.Method.Name)
+		function, ok := g.scope.GetFunction(name)
+		if !ok {
+			log.Fatalf("Method %q was not declared", name)
+		}
+
+		return g.call(function, ret, nil, append([]gooryvalues.Value{receiver}, args...)...)
+	}
+
+	_, index := iface.Method(node.Method.Name)
+	data := g.parentBlock.Extractvalue(receiver, 0)
+	vtable := g.parentBlock.Extractvalue(receiver, 1)
+	ptr := g.parentBlock.Getelementptr(gtypes.NewPointerType(node.Method.Type.Signature()), vtable,
+		goory.Constant(goory.IntType(32), 0),
+		goory.Constant(goory.IntType(32), index))
+
+	return g.call(g.parentBlock.Load(ptr), ret, data, args...)
+}
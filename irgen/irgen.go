@@ -13,7 +13,6 @@ import (
 
 	"reflect"
 
-	instructions "github.com/bongo227/goory/instructions"
 	gtypes "github.com/bongo227/goory/types"
 	gooryvalues "github.com/bongo227/goory/value"
 	"github.com/k0kubun/pp"
@@ -24,35 +23,130 @@ type Irgen struct {
 	module      *goory.Module
 	parentBlock *goory.Block
 	scope       *Scope
+	cleanup     *cleanup
+
+	// result points to the memory the current procedure returns an array in
+	result gooryvalues.Value
+
+	// Allocator generates the runtime procedures heap memory comes from
+	Allocator Allocator
+	alloc     *goory.Function
+	free      *goory.Function
+
+	// BoundsCheck enables runtime checks on array and slice indexes,
+	// OverflowTrap aborts on signed integer overflow instead of wrapping and
+	// File is the source file reported when a check fails
+	BoundsCheck  bool
+	OverflowTrap bool
+	File         string
+	declarations map[string]*goory.Function
+
+	// strings counts the string literals so each constant has a unique name
+	strings int
+
+	// init stores the values of globals that arent constant
+	init *goory.Function
 }
 
 func NewIrgen(tree *ast.Ast) *Irgen {
 	return &Irgen{
-		tree:   tree,
-		module: goory.NewModule("test"),
-		scope:  NewScope(),
+		tree:        tree,
+		module:      goory.NewModule("test"),
+		scope:       NewScope(),
+		Allocator:   MallocAllocator{},
+		BoundsCheck: true,
 	}
 }
 
 func (g *Irgen) Generate() string {
-	for _, f := range g.tree.Functions {
+	// Declare every function before generating any bodies so calls dont depend
+	// on declaration order
+	for _, f := range g.procedures() {
+		g.declare(f)
+	}
+
+	g.vtables()
+	g.globals()
+	if g.init != nil {
+		g.parentBlock.RetVoid()
+	}
+
+	for _, f := range g.procedures() {
 		g.function(f)
 	}
 
 	return g.module.LLVM()
 }
 
-func (g *Irgen) function(node *ast.FunctionDeclaration) {
-	// Create new function in module
-	fName := node.Name.Value.Value()
-	f := g.module.NewFunction(fName, node.Return.Llvm())
+// declare creates a new function in the module and adds it to the root scope
+func (g *Irgen) declare(node *ast.FunctionDeclaration) {
+	fName := functionName(node)
+	f := g.module.NewFunction(fName, returnType(node.Return))
+
+	// Pure functions let llvm eliminate and hoist repeated calls, arrays passed
+	// by pointer are memory they read or write
+	if node.Pure {
+		switch {
+		case types.Indirect(node.Return):
+			if !node.ReadOnly {
+				f.AddAttribute("argmemonly")
+			}
+		case node.ReadOnly || indirectArguments(node):
+			f.AddAttribute("readonly")
+		default:
+			f.AddAttribute("readnone")
+		}
+	}
 
 	g.scope.AddFunction(fName, f)
+}
+
+func (g *Irgen) function(node *ast.FunctionDeclaration) {
+	fName := functionName(node)
+	f, ok := g.scope.GetFunction(fName)
+	if !ok {
+		log.Fatalf("Function %q was not declared", fName)
+	}
+
+	// Arguments and locals live in the function scope
+	root := g.scope
+	g.scope = g.scope.Push()
+	defer func() { g.scope = root }()
+
 	g.parentBlock = f.Entry()
 
+	// Globals with non constant values are initialised before main runs
+	if fName == "main" && g.init != nil {
+		g.parentBlock.Call(g.init)
+	}
+
+	g.body(f, node)
+}
+
+// body generates the arguments and statements of the procedure into the
+// current block
+func (g *Irgen) body(f *goory.Function, node *ast.FunctionDeclaration) {
+	// Methods take the receiver before their arguments
+	arguments := node.Arguments
+	if node.Receiver != nil {
+		arguments = append([]*ast.ArgumentDeclaration{node.Receiver}, arguments...)
+	}
+
+	g.result = nil
+	if types.Indirect(node.Return) {
+		g.result = f.AddArgument(parameterType(node.Return), "result")
+	}
+
 	// Add arguments to function
-	for _, arg := range node.Arguments {
+	for _, arg := range arguments {
 		name := arg.Name.Value.Value()
+
+		// Arrays are already a copy in memory the procedure can modify
+		if types.Indirect(arg.Type) {
+			g.scope.AddVar(name, f.AddArgument(parameterType(arg.Type), name))
+			continue
+		}
+
 		argType := arg.Type.Llvm()
 		arg := f.AddArgument(argType, name)
 
@@ -61,7 +155,30 @@ func (g *Irgen) function(node *ast.FunctionDeclaration) {
 		g.scope.AddVar(name, alloc)
 	}
 
+	// Procedures with defer statements return through a cleanup block
+	g.cleanup = nil
+	if statements := defers(node.Body); len(statements) > 0 {
+		g.cleanup = g.newCleanup(node, statements)
+	}
+
 	g.block(node.Body)
+
+	// Procedures with no return value may fall off the end of the body, analysis
+	// guarantees any other unterminated block is unreachable
+	if !g.parentBlock.Terminated() {
+		switch {
+		case node.Return != nil:
+			g.parentBlock.Unreachable()
+		case g.cleanup != nil:
+			g.exit(nil)
+		default:
+			g.parentBlock.RetVoid()
+		}
+	}
+
+	if g.cleanup != nil {
+		g.runCleanup()
+	}
 }
 
 // TODO: remove this
@@ -100,6 +217,14 @@ func (g *Irgen) statement(node ast.Statement) {
 		g.assignmentSmt(node)
 	case *ast.ForStatement:
 		g.forSmt(node)
+	case *ast.SwitchStatement:
+		g.switchSmt(node)
+	case *ast.MatchStatement:
+		g.matchSmt(node)
+	case *ast.ExpressionStatement:
+		g.expression(node.Expression)
+	case *ast.DeferStatement:
+		g.deferSmt(node)
 	}
 }
 
@@ -142,13 +267,38 @@ func (g *Irgen) ifSmt(node *ast.IfStatment, block, endBlock *goory.Block) {
 }
 
 func (g *Irgen) returnSmt(node *ast.ReturnStatement) {
-	exp := g.expression(node.Result)
-	g.parentBlock.Ret(exp)
+	var exp gooryvalues.Value
+	switch {
+	case g.result != nil:
+		// Arrays are stored straight into the callers memory
+		g.arraySmt(node.Result, g.result)
+	case node.Result != nil:
+		exp = g.expression(node.Result)
+	}
+
+	switch {
+	case g.cleanup != nil:
+		g.exit(exp)
+	case exp == nil:
+		g.parentBlock.RetVoid()
+	default:
+		g.parentBlock.Ret(exp)
+	}
 }
 
 func (g *Irgen) declareSmt(node *ast.DeclareStatement) {
 	// TODO: handle function declarations
-	decl := node.Statement.(*ast.VaribleDeclaration)
+	switch decl := node.Statement.(type) {
+	case *ast.VaribleDeclaration:
+		g.varibleDcl(decl)
+	case *ast.TupleDeclaration:
+		g.tupleDcl(decl)
+	case *ast.ConstantDeclaration:
+		// Analysis substitutes the value wherever the constant is used
+	}
+}
+
+func (g *Irgen) varibleDcl(decl *ast.VaribleDeclaration) {
 	name := decl.Name.Value.Value()
 
 	log.Printf("Declaring %q", name)
@@ -168,59 +318,79 @@ func (g *Irgen) declareSmt(node *ast.DeclareStatement) {
 
 }
 
-func (g *Irgen) arraySmt(node ast.Expression, alloc *instructions.Alloca) {
+func (g *Irgen) tupleDcl(decl *ast.TupleDeclaration) {
+	values := g.elements(decl.Value, len(decl.Declarations))
+
+	for i, dcl := range decl.Declarations {
+		name := dcl.Name.Value.Value()
+		log.Printf("Declaring %q", name)
+
+		alloc := g.parentBlock.Alloca(dcl.Type.Llvm())
+		g.scope.AddVar(name, alloc)
+		g.parentBlock.Store(alloc, values[i])
+	}
+}
+
+// elements returns the value of each element of a tuple expression
+func (g *Irgen) elements(node ast.Expression, count int) []gooryvalues.Value {
+	values := make([]gooryvalues.Value, count)
+
+	// Tuple literals dont need to be packed into an aggregate first
+	if paren, ok := node.(*ast.ParenLiteralExpression); ok {
+		for i, element := range paren.Elements {
+			values[i] = g.expression(element)
+		}
+		return values
+	}
+
+	tuple := g.expression(node)
+	for i := range values {
+		values[i] = g.parentBlock.Extractvalue(tuple, i)
+	}
+
+	return values
+}
+
+func (g *Irgen) arraySmt(node ast.Expression, alloc gooryvalues.Value) {
 	switch node := node.(type) {
 	case *ast.BraceLiteralExpression:
 		for i, exp := range node.Elements {
-			// Analyse element of array
-			emt := g.expression(exp)
-
 			// Get a pointer to the index of the array
 			ptr := g.parentBlock.Getelementptr(node.Type.Base().Llvm(), alloc,
 				goory.Constant(goory.IntType(64), 0),
 				goory.Constant(goory.IntType(64), i))
 
+			// Nested array literals are stored element by element
+			if _, ok := node.Type.Base().(*types.Array); ok {
+				g.arraySmt(exp, ptr)
+				continue
+			}
+
 			// Store element in the array
-			g.parentBlock.Store(ptr, emt)
+			g.parentBlock.Store(ptr, g.expression(exp))
 		}
-	case *ast.CallExpression:
+	default:
 		array := g.expression(node)
 		g.parentBlock.Store(alloc, array)
-	default:
-		log.Fatalf("Cant assign type %q to array type", reflect.TypeOf(node).String())
 	}
 }
 
 func (g *Irgen) assignmentSmt(node *ast.AssignmentStatement) {
-	switch leftNode := node.Left.(type) {
-	case *ast.IdentExpression:
-		name := leftNode.Value.Value()
-		exp := g.expression(node.Right)
-
-		alloc, ok := g.scope.GetVar(name)
-		if !ok {
-			log.Fatalf("%q was not in scope", name)
-		}
-		g.parentBlock.Store(alloc, exp)
-		g.scope.AddVar(name, alloc)
-	case *ast.IndexExpression:
-		name := leftNode.Expression.(*ast.IdentExpression).Value.Value()
-		index := g.expression(leftNode.Index)
-		exp := g.expression(node.Right)
-
-		alloc, ok := g.scope.GetVar(name)
-		if !ok {
-			log.Fatalf("%q was not in scope", name)
+	// Destructuring assignments evaluate every value before storing any of them
+	if left, ok := node.Left.(*ast.ParenLiteralExpression); ok {
+		values := g.elements(node.Right, len(left.Elements))
+		for i, element := range left.Elements {
+			g.store(element, values[i])
 		}
-
-		arrayType := alloc.BaseType().(gtypes.ArrayType).BaseType()
-		arrayIndex := g.parentBlock.Getelementptr(arrayType, alloc,
-			goory.Constant(goory.IntType(64), 0), index)
-
-		g.parentBlock.Store(arrayIndex, exp)
-		g.scope.AddVar(name, alloc)
+		return
 	}
 
+	g.store(node.Left, g.expression(node.Right))
+}
+
+// store writes the value to the memory an addressable expression refers to
+func (g *Irgen) store(node ast.Expression, exp gooryvalues.Value) {
+	g.parentBlock.Store(g.address(node), exp)
 }
 
 func (g *Irgen) forSmt(node *ast.ForStatement) {
@@ -242,6 +412,143 @@ func (g *Irgen) forSmt(node *ast.ForStatement) {
 	g.parentBlock = continueBlock
 }
 
+func (g *Irgen) switchSmt(node *ast.SwitchStatement) {
+	function := g.parentBlock.Function()
+	endBlock := function.AddBlock()
+
+	// Create a block for each clause, control leaves through the default clause
+	// or the end block if their is no default
+	defaultBlock := endBlock
+	blocks := make([]*goory.Block, len(node.Clauses))
+	for i, clause := range node.Clauses {
+		blocks[i] = function.AddBlock()
+		if clause.IsDefault() {
+			defaultBlock = blocks[i]
+		}
+	}
+
+	if node.Tag != nil && constantSwitch(node) {
+		// Lower to a jump table
+		tag := g.expression(node.Tag)
+		sw := g.parentBlock.Switch(tag, defaultBlock)
+		for i, clause := range node.Clauses {
+			for _, exp := range clause.Expressions {
+				value, _ := intConstant(exp)
+				sw.AddCase(goory.Constant(tag.Type(), int(value)), blocks[i])
+			}
+		}
+	} else {
+		// Lower to a chain of conditional branches
+		var tag gooryvalues.Value
+		if node.Tag != nil {
+			tag = g.expression(node.Tag)
+		}
+
+		for i, clause := range node.Clauses {
+			for _, exp := range clause.Expressions {
+				var condition gooryvalues.Value
+				if tag == nil {
+					condition = g.expression(exp)
+				} else {
+					condition = g.equal(tag, g.expression(exp))
+				}
+
+				next := function.AddBlock()
+				g.parentBlock.CondBr(condition, blocks[i], next)
+				g.parentBlock = next
+			}
+		}
+		g.parentBlock.Br(defaultBlock)
+	}
+
+	// Generate clause bodies
+	for i, clause := range node.Clauses {
+		g.parentBlock = blocks[i]
+		g.block(clause.Body)
+		if g.parentBlock.Terminated() {
+			continue
+		}
+
+		if clause.Fallthrough() {
+			g.parentBlock.Br(blocks[i+1])
+		} else {
+			g.parentBlock.Br(endBlock)
+		}
+	}
+
+	g.parentBlock = endBlock
+}
+
+// constantSwitch returns true if every case of the switch is an integer constant
+func constantSwitch(node *ast.SwitchStatement) bool {
+	for _, clause := range node.Clauses {
+		for _, exp := range clause.Expressions {
+			if _, ok := intConstant(exp); !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// intConstant returns the value of an integer constant, analysis folds
+// constant expressions to a literal cast to their type if it isnt int
+func intConstant(node ast.Expression) (int64, bool) {
+	switch node := node.(type) {
+	case *ast.LiteralExpression:
+		if node.Value.Type() != lexer.INT {
+			return 0, false
+		}
+		value, err := strconv.ParseInt(node.Value.Value(), 0, 64)
+		return value, err == nil
+	case *ast.CastExpression:
+		if basic, ok := node.Type.(*types.Basic); ok && basic.Info()&types.IsInt != 0 {
+			return intConstant(node.Expression)
+		}
+	}
+
+	return 0, false
+}
+
+// equal compares two values of the same type, arrays are equal if every
+// element is
+func (g *Irgen) equal(left, right gooryvalues.Value) gooryvalues.Value {
+	if array, ok := left.Type().(gtypes.ArrayType); ok {
+		left, right := g.temporary(left), g.temporary(right)
+		result := goory.Constant(goory.BoolType(), true)
+		for i := 0; i < int(array.Length()); i++ {
+			index := []gooryvalues.Value{
+				goory.Constant(goory.IntType(64), 0),
+				goory.Constant(goory.IntType(64), i),
+			}
+			element := g.equal(
+				g.parentBlock.Load(g.parentBlock.Getelementptr(array.BaseType(), left, index...)),
+				g.parentBlock.Load(g.parentBlock.Getelementptr(array.BaseType(), right, index...)))
+			result = g.parentBlock.Select(result, element, goory.Constant(goory.BoolType(), false))
+		}
+		return result
+	}
+
+	switch left.Type() {
+	case goory.FloatType(), goory.DoubleType():
+		return g.parentBlock.Fcmp(goory.FloatOeq, left, right)
+	default:
+		return g.parentBlock.Icmp(goory.IntEq, left, right)
+	}
+}
+
+// less returns true if the left value is less than the right value of the
+// same type
+func (g *Irgen) less(left, right gooryvalues.Value) gooryvalues.Value {
+	switch left.Type() {
+	case goory.FloatType(), goory.DoubleType():
+		return g.parentBlock.Fcmp(goory.FloatOlt, left, right)
+	default:
+		return g.parentBlock.Icmp(goory.IntSlt, left, right)
+	}
+}
+
 func (g *Irgen) expression(node ast.Expression) gooryvalues.Value {
 	switch node := node.(type) {
 	case *ast.BinaryExpression:
@@ -256,29 +563,138 @@ func (g *Irgen) expression(node ast.Expression) gooryvalues.Value {
 		return g.callExp(node)
 	case *ast.IndexExpression:
 		return g.indexExp(node)
+	case *ast.UnaryExpression:
+		return g.unaryExp(node)
+	case *ast.ParenLiteralExpression:
+		return g.tupleExp(node)
+	case *ast.CompositionExpression:
+		return g.functionValue(compositionName(node), g.composition(node), node.Type)
+	case *ast.LambdaExpression:
+		return g.lambdaExp(node)
+	case *ast.VariantExpression:
+		return g.variantExp(node)
+	case *ast.MatchExpression:
+		return g.matchExp(node)
+	case *ast.BraceLiteralExpression:
+		return g.braceLiteralExp(node)
+	case *ast.FieldExpression:
+		return g.fieldExp(node)
+	case *ast.MethodCallExpression:
+		return g.methodCallExp(node)
+	case *ast.InterfaceExpression:
+		return g.interfaceExp(node)
 	default:
 		panic(fmt.Sprintf("Unknown expression node: %s", pp.Sprint(node)))
 	}
 }
 
+// braceLiteralExp builds the value of an array or struct literal
+func (g *Irgen) braceLiteralExp(node *ast.BraceLiteralExpression) gooryvalues.Value {
+	if _, ok := node.Type.(*types.Array); ok {
+		alloc := g.parentBlock.Alloca(node.Type.Llvm())
+		g.arraySmt(node, alloc)
+		return g.parentBlock.Load(alloc)
+	}
+
+	return g.structLiteralExp(node)
+}
+
+// tupleExp packs the elements into a struct aggregate
+func (g *Irgen) tupleExp(node *ast.ParenLiteralExpression) gooryvalues.Value {
+	values := make([]gooryvalues.Value, len(node.Elements))
+	for i, element := range node.Elements {
+		values[i] = g.expression(element)
+	}
+
+	return g.aggregate(values...)
+}
+
+// aggregate builds a struct value from the values
+func (g *Irgen) aggregate(values ...gooryvalues.Value) gooryvalues.Value {
+	valueTypes := make([]gtypes.Type, len(values))
+	for i, value := range values {
+		valueTypes[i] = value.Type()
+	}
+
+	alloc := g.parentBlock.Alloca(gtypes.NewStructType(valueTypes...))
+	for i, value := range values {
+		ptr := g.parentBlock.Getelementptr(valueTypes[i], alloc,
+			goory.Constant(goory.IntType(32), 0),
+			goory.Constant(goory.IntType(32), i))
+		g.parentBlock.Store(ptr, value)
+	}
+
+	return g.parentBlock.Load(alloc)
+}
+
 func (g *Irgen) indexExp(node *ast.IndexExpression) gooryvalues.Value {
-	alloc, _ := g.scope.GetVar(node.Expression.(*ast.IdentExpression).Value.Value())
-	index := g.expression(node.Index)
-	// TODO: Make getting base type cleaner
-	ptr := g.parentBlock.Getelementptr(alloc.BaseType().(gtypes.ArrayType).BaseType(), alloc, goory.Constant(goory.IntType(32), 0), index)
-	value := g.parentBlock.Load(ptr)
+	return g.parentBlock.Load(g.address(node))
+}
+
+// address returns a pointer to the memory an addressable expression refers to
+func (g *Irgen) address(node ast.Expression) gooryvalues.Value {
+	switch node := node.(type) {
+	case *ast.IdentExpression:
+		name := node.Value.Value()
+		alloc, ok := g.scope.GetVar(name)
+		if !ok {
+			log.Fatalf("%q was not in scope", name)
+		}
+		return alloc
 
-	return value
+	case *ast.UnaryExpression:
+		// Address of a dereference is the pointer itself
+		return g.expression(node.Expression)
+
+	case *ast.IndexExpression:
+		array := g.address(node.Expression)
+
+		// Arrays are indexed through pointers automaticly
+		if _, ok := pointee(array).(gtypes.PointerType); ok {
+			array = g.parentBlock.Load(array)
+		}
+
+		index := g.expression(node.Index)
+
+		// Slices are indexed through their data pointer
+		if _, ok := pointee(array).(gtypes.StructType); ok {
+			header := g.parentBlock.Load(array)
+			data := g.parentBlock.Extractvalue(header, 0)
+			if g.BoundsCheck {
+				g.boundsCheck(node, index, g.parentBlock.Extractvalue(header, 1))
+			}
+			return g.parentBlock.Getelementptr(pointee(data), data, index)
+		}
+
+		arrayType := pointee(array).(gtypes.ArrayType)
+		if g.BoundsCheck && !inBounds(node.Index, arrayType.Length()) {
+			g.boundsCheck(node, index,
+				goory.Constant(goory.IntType(64), arrayType.Length()))
+		}
+
+		elementType := arrayType.BaseType()
+		return g.parentBlock.Getelementptr(elementType, array,
+			goory.Constant(goory.IntType(64), 0), index)
+
+	case *ast.FieldExpression:
+		return g.parentBlock.Getelementptr(node.Type.Llvm(), g.address(node.Expression),
+			goory.Constant(goory.IntType(32), 0),
+			goory.Constant(goory.IntType(32), node.Index))
+	}
+
+	// Values that are not stored anywhere, such as the result of a call, are
+	// copied to the stack so they can be indexed
+	return g.temporary(g.expression(node))
 }
 
-func (g *Irgen) callExp(node *ast.CallExpression) gooryvalues.Value {
-	// TODO: handle lambda's (i.e. functions that are not called by name)
-	funcName := node.Function.(*ast.IdentExpression).Value.Value()
+// pointee returns the type a pointer value points to
+func pointee(pointer gooryvalues.Value) gtypes.Type {
+	return pointer.Type().(gtypes.PointerType).BaseType()
+}
 
-	log.Printf("Function name: %q", funcName)
-	function, ok := g.scope.GetFunction(funcName)
-	if !ok {
-		log.Fatalf("Function %q not in scope", funcName)
+func (g *Irgen) callExp(node *ast.CallExpression) gooryvalues.Value {
+	if node.Builtin != nil {
+		return g.builtinExp(node.Builtin.Name, node)
 	}
 
 	args := make([]gooryvalues.Value, len(node.Arguments.Elements))
@@ -286,35 +702,79 @@ func (g *Irgen) callExp(node *ast.CallExpression) gooryvalues.Value {
 		args[i] = g.expression(element)
 	}
 
-	return g.parentBlock.Call(function, args...)
+	// Calls to generic procedures are calls to the instance for the type arguments
+	if len(node.TypeArguments) > 0 {
+		funcName := mangle(node.Function.(*ast.IdentExpression).Value.Value(), node.TypeArguments)
+		function, ok := g.scope.GetFunction(funcName)
+		if !ok {
+			log.Fatalf("Instance %q was not declared", funcName)
+		}
+		return g.call(function, node.Type.Return(), nil, args...)
+	}
+
+	if function, ok := g.direct(node.Function); ok {
+		return g.call(function, node.Type.Return(), nil, args...)
+	}
+
+	return g.callValue(g.expression(node.Function), node.Type.Return(), args...)
+}
+
+func (g *Irgen) unaryExp(node *ast.UnaryExpression) gooryvalues.Value {
+	switch node.Operator.Type() {
+	case lexer.AND:
+		return g.address(node.Expression)
+	case lexer.MUL:
+		return g.parentBlock.Load(g.expression(node.Expression))
+	}
+
+	exp := g.expression(node.Expression)
+	if node.Operator.Type() != lexer.SUB {
+		return exp
+	}
+
+	switch exp.Type() {
+	case goory.FloatType(), goory.DoubleType():
+		return g.parentBlock.Fsub(goory.Constant(exp.Type(), 0.0), exp)
+	default:
+		return g.negate(exp, node.Operator)
+	}
 }
 
 func (g *Irgen) identExp(node *ast.IdentExpression) gooryvalues.Value {
 	ident := node.Value.Value()
 
-	// TODO: do this with a map
-	if ident == "true" {
-		return goory.Constant(goory.BoolType(), true)
-	} else if ident == "false" {
-		return goory.Constant(goory.BoolType(), false)
+	item, ok := g.scope.GetVar(ident)
+	if ok {
+		return g.parentBlock.Load(item)
 	}
 
-	item, ok := g.scope.GetVar(ident)
-	if !ok {
-		log.Fatalf("%q was not is scope", ident)
+	// Procedures used as values
+	if f, ok := g.scope.GetFunction(ident); ok {
+		return g.functionValue(ident, f, g.signature(ident))
+	}
+
+	// Predeclared constants
+	if builtin, ok := ast.Universe.Lookup(ident).(*ast.Builtin); ok && builtin.Type != nil {
+		return goory.Constant(builtin.Type.Llvm(), builtin.Value)
 	}
 
-	return g.parentBlock.Load(item)
+	log.Fatalf("%q was not is scope", ident)
+	return nil
 }
 
 func (g *Irgen) literalExp(node *ast.LiteralExpression) gooryvalues.Value {
 	switch node.Value.Type() {
 	case lexer.INT:
-		value, _ := strconv.Atoi(node.Value.Value())
+		value, err := strconv.ParseInt(node.Value.Value(), 0, 64)
+		if err != nil {
+			log.Fatalf("Invalid integer literal %q", node.Value.Value())
+		}
 		return goory.Constant(types.IntType(0).Llvm(), value)
 	case lexer.FLOAT:
 		value, _ := strconv.ParseFloat(node.Value.Value(), 64)
 		return goory.Constant(types.FloatType(0).Llvm(), value)
+	case lexer.STRING:
+		return g.stringExp(node)
 	default:
 		panic("Unknown literal type")
 	}
@@ -322,6 +782,24 @@ func (g *Irgen) literalExp(node *ast.LiteralExpression) gooryvalues.Value {
 
 func (g *Irgen) castExp(node *ast.CastExpression) gooryvalues.Value {
 	exp := g.expression(node.Expression)
+
+	// Arrays are only converted to named types with the same layout
+	if types.Indirect(node.Type) {
+		return exp
+	}
+
+	// Strings are converted to and from runes by encoding or decoding UTF-8
+	_, fromString := exp.Type().(gtypes.StructType)
+	toString := types.Underlying(node.Type) == types.BasicString
+	switch {
+	case fromString && toString:
+		return exp
+	case toString:
+		return g.parentBlock.Call(g.encodeFunction(), g.parentBlock.Cast(exp, goory.IntType(32)))
+	case fromString:
+		return g.parentBlock.Call(g.decodeFunction(), exp)
+	}
+
 	return g.parentBlock.Cast(exp, node.Type.Llvm())
 }
 
@@ -333,6 +811,14 @@ func (g *Irgen) binaryExp(node *ast.BinaryExpression) gooryvalues.Value {
 
 	log.Printf("Left is %q, right is %q", left.Type().String(), right.Type().String())
 
+	if _, ok := left.Type().(gtypes.ArrayType); ok {
+		equal := g.equal(left, right)
+		if node.Operator.Type() == lexer.NEQ {
+			return g.parentBlock.Icmp(goory.IntEq, equal, goory.Constant(goory.BoolType(), false))
+		}
+		return equal
+	}
+
 	if node.IsFp {
 		switch node.Operator.Type() {
 		case lexer.ADD:
@@ -351,8 +837,16 @@ func (g *Irgen) binaryExp(node *ast.BinaryExpression) gooryvalues.Value {
 			return g.parentBlock.Fcmp(goory.FloatOgt, left, right)
 		case lexer.LSS:
 			return g.parentBlock.Fcmp(goory.FloatOlt, left, right)
+		case lexer.LEQ:
+			return g.parentBlock.Fcmp(goory.FloatOle, left, right)
+		case lexer.GEQ:
+			return g.parentBlock.Fcmp(goory.FloatOge, left, right)
 		}
 	} else {
+		if _, ok := intrinsics[node.Operator.Type()]; ok && g.OverflowTrap {
+			return g.checked(node, left, right)
+		}
+
 		switch node.Operator.Type() {
 		case lexer.ADD:
 			return g.parentBlock.Add(left, right)
@@ -360,8 +854,8 @@ func (g *Irgen) binaryExp(node *ast.BinaryExpression) gooryvalues.Value {
 			return g.parentBlock.Sub(left, right)
 		case lexer.MUL:
 			return g.parentBlock.Mul(left, right)
-		case lexer.QUO:
-			return g.parentBlock.Div(left, right)
+		case lexer.QUO, lexer.REM:
+			return g.divide(node, left, right)
 		case lexer.EQL:
 			return g.parentBlock.Icmp(goory.IntEq, left, right)
 		case lexer.NEQ:
@@ -370,8 +864,10 @@ func (g *Irgen) binaryExp(node *ast.BinaryExpression) gooryvalues.Value {
 			return g.parentBlock.Icmp(goory.IntSgt, left, right)
 		case lexer.LSS:
 			return g.parentBlock.Icmp(goory.IntSlt, left, right)
-		case lexer.REM:
-			return g.parentBlock.Srem(left, right)
+		case lexer.LEQ:
+			return g.parentBlock.Icmp(goory.IntSle, left, right)
+		case lexer.GEQ:
+			return g.parentBlock.Icmp(goory.IntSge, left, right)
 		}
 	}
 
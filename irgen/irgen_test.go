@@ -145,3 +145,76 @@ func TestIrgen(t *testing.T) {
 		}
 	}
 }
+
+func TestBoundsPanic(t *testing.T) {
+	source := `proc main :: -> i32 {
+		items := i32[3]{1, 2, 3}
+		i := 3
+		return items[i]
+	}`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := analysis.NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+	gen := NewIrgen(tree)
+	gen.File = "bounds.fur"
+	llvm := gen.Generate()
+
+	code, msg := runIr(llvm)
+	if code != 2 || !strings.Contains(msg, "bounds.fur:3:16: index out of range [3] with length 3") {
+		t.Errorf("\nIr:\n%s\nReturn Code: %d\nOut: %s", llvm, code, msg)
+	}
+}
+
+func TestOverflowTrap(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{
+			"addition",
+			`proc main :: -> i32 {
+				i8 a = 127
+				a = a + 1
+				return 123
+			}`,
+		},
+		{
+			"negation",
+			`proc main :: -> i32 {
+				i8 a = -128
+				a = -a
+				return 123
+			}`,
+		},
+		{
+			"absolute value",
+			`proc main :: -> i32 {
+				i8 a = -128
+				a = abs(a)
+				return 123
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.NewLexer([]byte(c.source)).Lex()
+		if err != nil {
+			t.Error(err)
+		}
+
+		tree := analysis.NewAnalysis(parser.NewParser(tokens, true).Parse()).Analalize()
+		gen := NewIrgen(tree)
+		gen.File = "overflow.fur"
+		gen.OverflowTrap = true
+		llvm := gen.Generate()
+
+		code, msg := runIr(llvm)
+		if code != 2 || !strings.Contains(msg, "integer overflow") {
+			t.Errorf("\n%s\nIr:\n%s\nReturn Code: %d\nOut: %s", c.name, llvm, code, msg)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package irgen
+
+import (
+	"log"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// returnType returns the llvm return type, procedures with no return value
+// or that return an array through a pointer return void
+func returnType(typ types.Type) gtypes.Type {
+	if typ == nil || types.Indirect(typ) {
+		return goory.VoidType()
+	}
+
+	return typ.Llvm()
+}
+
+// signature returns the type of the top level procedure with the name
+func (g *Irgen) signature(name string) *types.Function {
+	for _, f := range g.tree.Functions {
+		if f.Receiver != nil || f.Name.Value.Value() != name {
+			continue
+		}
+
+		argTypes := make([]types.Type, len(f.Arguments))
+		for i, arg := range f.Arguments {
+			argTypes[i] = arg.Type
+		}
+		return types.NewFunction(f.Return, argTypes...)
+	}
+
+	log.Fatalf("Function %q not declared", name)
+	return nil
+}
+
+// nilEnvironment is the environment of function values that capture nothing
+func (g *Irgen) nilEnvironment() gooryvalues.Value {
+	return g.parentBlock.Cast(goory.Constant(goory.IntType(64), 0), bytePointer())
+}
+
+// functionValue returns a function value for the procedure, calls through the
+// value go via a wrapper that drops the environment
+func (g *Irgen) functionValue(name string, f *goory.Function, typ *types.Function) gooryvalues.Value {
+	name += ".value"
+	wrapper, ok := g.declarations[name]
+	if !ok {
+		if g.declarations == nil {
+			g.declarations = make(map[string]*goory.Function)
+		}
+
+		wrapper = g.module.NewFunction(name, returnType(typ.Return()))
+		g.declarations[name] = wrapper
+
+		wrapper.AddArgument(bytePointer(), "env")
+		result, args := parameters(wrapper, typ)
+		forward(wrapper.Entry(), f, typ.Return(), result, args...)
+	}
+
+	return g.aggregate(wrapper, g.nilEnvironment())
+}
+
+// callValue calls the code of a function value with its environment
+func (g *Irgen) callValue(value gooryvalues.Value, ret types.Type, args ...gooryvalues.Value) gooryvalues.Value {
+	code := g.parentBlock.Extractvalue(value, 0)
+	env := g.parentBlock.Extractvalue(value, 1)
+
+	return g.call(code, ret, env, args...)
+}
+
+// environmentType returns the type of the struct the captured varibles are
+// copied into
+func environmentType(node *ast.LambdaExpression) *types.Tuple {
+	captureTypes := make([]types.Type, len(node.Captures))
+	for i, capture := range node.Captures {
+		captureTypes[i] = capture.Type
+	}
+
+	return types.NewTuple(captureTypes...)
+}
+
+func (g *Irgen) lambdaExp(node *ast.LambdaExpression) gooryvalues.Value {
+	env := g.environment(node)
+	return g.aggregate(g.lambda(node), env)
+}
+
+// environment copies the values of the captured varibles into memory from the
+// allocator, freeing the function value releases it
+func (g *Irgen) environment(node *ast.LambdaExpression) gooryvalues.Value {
+	if len(node.Captures) == 0 {
+		return g.nilEnvironment()
+	}
+
+	values := make([]gooryvalues.Value, len(node.Captures))
+	for i, capture := range node.Captures {
+		values[i] = g.identExp(capture.Name)
+	}
+
+	typ := environmentType(node)
+	size := goory.Constant(goory.IntType(64), types.Sizeof(typ))
+	env := g.parentBlock.Call(g.allocFunction(), size)
+	ptr := g.parentBlock.Cast(env, gtypes.NewPointerType(typ.Llvm()))
+	g.parentBlock.Store(ptr, g.aggregate(values...))
+
+	return env
+}
+
+// lambda generates the procedure for the body of the lambda, it takes the
+// environment before its arguments
+func (g *Irgen) lambda(node *ast.LambdaExpression) *goory.Function {
+	block, scope, cleanup, result := g.parentBlock, g.scope, g.cleanup, g.result
+	defer func() {
+		g.parentBlock, g.scope, g.cleanup, g.result = block, scope, cleanup, result
+	}()
+
+	function := node.Function
+	f := g.module.NewFunction(function.Name.Value.Value(), returnType(function.Return))
+	env := f.AddArgument(bytePointer(), "env")
+
+	// The body can only see globals and the copies of the captured varibles
+	g.scope = g.scope.Root().Push()
+	g.parentBlock = f.Entry()
+
+	if len(node.Captures) > 0 {
+		typ := environmentType(node).Llvm()
+		values := g.parentBlock.Load(g.parentBlock.Cast(env, gtypes.NewPointerType(typ)))
+		for i, capture := range node.Captures {
+			alloc := g.parentBlock.Alloca(capture.Type.Llvm())
+			g.parentBlock.Store(alloc, g.parentBlock.Extractvalue(values, i))
+			g.scope.AddVar(capture.Name.Value.Value(), alloc)
+		}
+	}
+
+	g.body(f, function)
+	return f
+}
@@ -0,0 +1,94 @@
+package irgen
+
+import (
+	"fmt"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// Integer arithmetic wraps around on overflow. LLVM add, sub and mul wrap when
+// no nsw/nuw flags are set, division is the only operation that needs help.
+
+// intrinsics maps operators to the llvm overflow intrinsic that checks them
+var intrinsics = map[lexer.TokenType]string{
+	lexer.ADD: "sadd",
+	lexer.SUB: "ssub",
+	lexer.MUL: "smul",
+}
+
+// checked performs the operation with an overflow intrinsic, calling the
+// overflow panic routine if the result wrapped
+func (g *Irgen) checked(node *ast.BinaryExpression, left, right gooryvalues.Value) gooryvalues.Value {
+	result, overflowed := g.withOverflow(intrinsics[node.Operator.Type()], left, right)
+	g.overflowCheck(node.Operator, overflowed)
+	return result
+}
+
+// withOverflow calls llvm.<op>.with.overflow, returning the result and if it overflowed
+func (g *Irgen) withOverflow(op string, left, right gooryvalues.Value) (result, overflowed gooryvalues.Value) {
+	typ := left.Type()
+	intrinsic := g.declaration(fmt.Sprintf("llvm.%s.with.overflow.%s", op, typ.String()),
+		gtypes.NewStructType(typ, goory.BoolType()), typ, typ)
+
+	pair := g.parentBlock.Call(intrinsic, left, right)
+	return g.parentBlock.Extractvalue(pair, 0), g.parentBlock.Extractvalue(pair, 1)
+}
+
+// overflowCheck calls the overflow panic routine with the position of the
+// operator if the operation overflowed
+func (g *Irgen) overflowCheck(operator lexer.Token, overflowed gooryvalues.Value) {
+	routine := g.panicFunction("fur_overflow_panic", "integer overflow")
+	g.check(overflowed, operator, routine)
+}
+
+// negate returns 0 - value. Negating the smallest integer overflows, so it is
+// checked like a subtraction at the position of the token
+func (g *Irgen) negate(value gooryvalues.Value, position lexer.Token) gooryvalues.Value {
+	zero := goory.Constant(value.Type(), 0)
+	if g.OverflowTrap {
+		result, overflowed := g.withOverflow("ssub", zero, value)
+		g.overflowCheck(position, overflowed)
+		return result
+	}
+
+	return g.parentBlock.Sub(zero, value)
+}
+
+// divide performs signed division or remainder. The smallest integer divided
+// by -1 overflows, which is undefined in llvm, so the divisor is replaced with
+// 1 and the result negated (wrapping back to the smallest integer) instead.
+func (g *Irgen) divide(node *ast.BinaryExpression, left, right gooryvalues.Value) gooryvalues.Value {
+	remainder := node.Operator.Type() == lexer.REM
+
+	// Constant divisors other than -1 cant overflow
+	if value, ok := intConstant(node.Right); ok && value != -1 {
+		if remainder {
+			return g.parentBlock.Srem(left, right)
+		}
+		return g.parentBlock.Div(left, right)
+	}
+
+	typ := left.Type()
+	zero := goory.Constant(typ, 0)
+	isNegOne := g.parentBlock.Icmp(goory.IntEq, right, goory.Constant(typ, -1))
+
+	if g.OverflowTrap && !remainder {
+		// Negating left overflows exactly when left is the smallest integer
+		_, overflowed := g.withOverflow("ssub", zero, left)
+		overflowed = g.parentBlock.Select(isNegOne, overflowed,
+			goory.Constant(goory.BoolType(), false))
+		g.overflowCheck(node.Operator, overflowed)
+	}
+
+	divisor := g.parentBlock.Select(isNegOne, goory.Constant(typ, 1), right)
+	if remainder {
+		return g.parentBlock.Select(isNegOne, zero, g.parentBlock.Srem(left, divisor))
+	}
+
+	return g.parentBlock.Select(isNegOne, g.parentBlock.Sub(zero, left),
+		g.parentBlock.Div(left, divisor))
+}
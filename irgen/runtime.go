@@ -0,0 +1,76 @@
+package irgen
+
+import (
+	"fmt"
+
+	"github.com/bongo227/Furlang/lexer"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// declaration returns the external function with the name, declaring it on
+// first use
+func (g *Irgen) declaration(name string, returnType gtypes.Type, argTypes ...gtypes.Type) *goory.Function {
+	if g.declarations == nil {
+		g.declarations = make(map[string]*goory.Function)
+	}
+
+	if f, ok := g.declarations[name]; ok {
+		return f
+	}
+
+	f := g.module.NewDeclaration(name, returnType, argTypes...)
+	g.declarations[name] = f
+	return f
+}
+
+// panicFunction returns the runtime procedure with the name, generating it on
+// first use. It takes the line and column of the failure followed by an i64
+// for each argument name, prints the message formatted with them and exits.
+func (g *Irgen) panicFunction(name, message string, arguments ...string) *goory.Function {
+	if f, ok := g.declarations[name]; ok {
+		return f
+	}
+
+	printf := g.declaration("printf", goory.IntType(32), bytePointer())
+	printf.SetVariadic(true)
+	exit := g.declaration("exit", goory.VoidType(), goory.IntType(32))
+
+	f := g.module.NewFunction(name, goory.VoidType())
+	g.declarations[name] = f
+
+	format := g.module.NewString(name+"_message", fmt.Sprintf("%s:%%ld:%%ld: %s\n", g.File, message))
+	values := []gooryvalues.Value{
+		format,
+		f.AddArgument(goory.IntType(64), "line"),
+		f.AddArgument(goory.IntType(64), "column"),
+	}
+	for _, arg := range arguments {
+		values = append(values, f.AddArgument(goory.IntType(64), arg))
+	}
+
+	block := f.Entry()
+	block.Call(printf, values...)
+	block.Call(exit, goory.Constant(goory.IntType(32), 2))
+	block.Unreachable()
+
+	return f
+}
+
+// check calls the panic routine with the position of the token and the
+// arguments if failed is true, execution continues in a new block otherwise
+func (g *Irgen) check(failed gooryvalues.Value, position lexer.Token, routine *goory.Function, arguments ...gooryvalues.Value) {
+	okBlock := g.parentBlock.Function().AddBlock()
+	failBlock := g.parentBlock.Function().AddBlock()
+	g.parentBlock.CondBr(failed, failBlock, okBlock)
+
+	values := []gooryvalues.Value{
+		goory.Constant(goory.IntType(64), position.Line()),
+		goory.Constant(goory.IntType(64), position.Column()),
+	}
+	failBlock.Call(routine, append(values, arguments...)...)
+	failBlock.Unreachable()
+
+	g.parentBlock = okBlock
+}
@@ -1,7 +1,7 @@
 package irgen
 
 import "github.com/bongo227/goory"
-import instructions "github.com/bongo227/goory/instructions"
+import gooryvalues "github.com/bongo227/goory/value"
 
 type Scope struct {
 	parentScope *Scope // TODO: parentScope -> parent
@@ -10,7 +10,7 @@ type Scope struct {
 
 type scopes struct {
 	functions map[string]*goory.Function
-	varibles  map[string]*instructions.Alloca
+	varibles  map[string]gooryvalues.Value
 }
 
 func NewScope() *Scope {
@@ -18,7 +18,7 @@ func NewScope() *Scope {
 		parentScope: nil,
 		scope: &scopes{
 			functions: make(map[string]*goory.Function),
-			varibles:  make(map[string]*instructions.Alloca),
+			varibles:  make(map[string]gooryvalues.Value),
 		},
 	}
 }
@@ -28,18 +28,18 @@ func (s *Scope) Push() *Scope {
 		parentScope: s,
 		scope: &scopes{
 			functions: make(map[string]*goory.Function),
-			varibles:  make(map[string]*instructions.Alloca),
+			varibles:  make(map[string]gooryvalues.Value),
 		},
 	}
 }
 
-// AddVar adds a value to the current scope
-func (s *Scope) AddVar(key string, value *instructions.Alloca) {
+// AddVar adds a pointer to a varible to the current scope
+func (s *Scope) AddVar(key string, value gooryvalues.Value) {
 	s.scope.varibles[key] = value
 }
 
 // GetLocalVar returns the item and true if the key is in local scope, otherwise false
-func (s *Scope) GetLocalVar(key string) (*instructions.Alloca, bool) {
+func (s *Scope) GetLocalVar(key string) (gooryvalues.Value, bool) {
 	if item, ok := s.scope.varibles[key]; ok {
 		return item, true
 	}
@@ -49,7 +49,7 @@ func (s *Scope) GetLocalVar(key string) (*instructions.Alloca, bool) {
 
 // GetVar returns the item and true if the key is in local (or parent scope),
 // otherwise false
-func (s *Scope) GetVar(key string) (*instructions.Alloca, bool) {
+func (s *Scope) GetVar(key string) (gooryvalues.Value, bool) {
 	for s.parentScope != nil {
 		if item, ok := s.scope.varibles[key]; ok {
 			return item, true
@@ -90,3 +90,12 @@ func (s *Scope) GetFunction(key string) (*goory.Function, bool) {
 	// Check root scope (parentScope will be nil)
 	return s.GetLocalFunction(key)
 }
+
+// Root returns the outermost scope, which holds the functions and globals
+func (s *Scope) Root() *Scope {
+	for s.parentScope != nil {
+		s = s.parentScope
+	}
+
+	return s
+}
@@ -0,0 +1,204 @@
+package irgen
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// replacementChar is the rune invalid runes and invalid UTF-8 are converted to
+const replacementChar = 0xFFFD
+
+// utf8Lengths are the largest rune and the lead byte of each UTF-8 encoding
+// length, starting with one byte
+var utf8Lengths = []struct {
+	max  int64
+	lead int64
+}{
+	{0x7F, 0x00},
+	{0x7FF, 0xC0},
+	{0xFFFF, 0xE0},
+	{0x10FFFF, 0xF0},
+}
+
+// stringExp lowers a string literal to a string of a constant holding its bytes
+func (g *Irgen) stringExp(node *ast.LiteralExpression) gooryvalues.Value {
+	value, err := strconv.Unquote(node.Value.Value())
+	if err != nil {
+		log.Fatalf("Invalid string literal %s", node.Value.Value())
+	}
+
+	data := g.module.NewString(fmt.Sprintf("fur_string%d", g.strings), value)
+	g.strings++
+	return g.aggregate(data, goory.Constant(goory.IntType(64), int64(len(value))))
+}
+
+// runtimeFunction returns the runtime procedure with the name, if it has not
+// been generated yet it is created and generate is called to build its body
+func (g *Irgen) runtimeFunction(name string, returnType gtypes.Type, generate func(f *goory.Function)) *goory.Function {
+	if f, ok := g.declarations[name]; ok {
+		return f
+	}
+	if g.declarations == nil {
+		g.declarations = make(map[string]*goory.Function)
+	}
+
+	f := g.module.NewFunction(name, returnType)
+	g.declarations[name] = f
+
+	parent := g.parentBlock
+	g.parentBlock = f.Entry()
+	generate(f)
+	g.parentBlock = parent
+
+	return f
+}
+
+// encodeFunction returns fur_encode_rune, which allocates the UTF-8 encoding
+// of a rune as a string, the caller frees it
+func (g *Irgen) encodeFunction() *goory.Function {
+	return g.runtimeFunction("fur_encode_rune", types.BasicString.Llvm(), func(f *goory.Function) {
+		r := f.AddArgument(goory.IntType(32), "r")
+
+		// Negative runes, surrogates and runes past the last code point are
+		// encoded as the replacement character
+		value := g.parentBlock.Cast(r, goory.IntType(64))
+		replacement := goory.Constant(goory.IntType(64), replacementChar)
+		value = g.parentBlock.Select(g.compare(goory.IntSlt, value, 0), replacement, value)
+		value = g.parentBlock.Select(g.compare(goory.IntSgt, value, 0x10FFFF), replacement, value)
+		surrogate := g.parentBlock.Select(g.compare(goory.IntSge, value, 0xD800),
+			g.compare(goory.IntSle, value, 0xDFFF), goory.Constant(goory.BoolType(), false))
+		value = g.parentBlock.Select(surrogate, replacement, value)
+
+		data := g.parentBlock.Call(g.allocFunction(), goory.Constant(goory.IntType(64), 4))
+
+		// Each encoding length gets a block storing the lead byte followed by
+		// six bits of the rune in each continuation byte
+		for i, length := range utf8Lengths {
+			size := i + 1
+			if size < len(utf8Lengths) {
+				encode, next := f.AddBlock(), f.AddBlock()
+				g.parentBlock.CondBr(g.compare(goory.IntSle, value, length.max), encode, next)
+				g.parentBlock = encode
+				g.encode(data, value, size, length.lead)
+				g.parentBlock = next
+				continue
+			}
+			g.encode(data, value, size, length.lead)
+		}
+	})
+}
+
+// encode stores the rune in the data as a UTF-8 sequence of the size and
+// returns it as a string
+func (g *Irgen) encode(data, value gooryvalues.Value, size int, lead int64) {
+	for i := 0; i < size; i++ {
+		divisor := int64(1) << uint(6*(size-1-i))
+		bits := g.parentBlock.Div(value, goory.Constant(goory.IntType(64), divisor))
+
+		var b gooryvalues.Value
+		if i == 0 {
+			b = g.parentBlock.Add(bits, goory.Constant(goory.IntType(64), lead))
+		} else {
+			bits = g.parentBlock.Srem(bits, goory.Constant(goory.IntType(64), 64))
+			b = g.parentBlock.Add(bits, goory.Constant(goory.IntType(64), 0x80))
+		}
+
+		ptr := g.parentBlock.Getelementptr(goory.IntType(8), data, goory.Constant(goory.IntType(64), i))
+		g.parentBlock.Store(ptr, g.parentBlock.Cast(b, goory.IntType(8)))
+	}
+
+	g.parentBlock.Ret(g.aggregate(data, goory.Constant(goory.IntType(64), size)))
+}
+
+// decodeFunction returns fur_decode_rune, which returns the first rune of a
+// string, the replacement character if it is empty or not valid UTF-8
+func (g *Irgen) decodeFunction() *goory.Function {
+	return g.runtimeFunction("fur_decode_rune", goory.IntType(32), func(f *goory.Function) {
+		s := f.AddArgument(types.BasicString.Llvm(), "s")
+
+		invalid := f.AddBlock()
+		invalid.Ret(goory.Constant(goory.IntType(32), replacementChar))
+
+		data := g.parentBlock.Extractvalue(s, 0)
+		length := g.parentBlock.Extractvalue(s, 1)
+		g.require(g.compare(goory.IntSgt, length, 0), invalid)
+
+		// A single byte rune
+		lead := g.byteAt(data, 0)
+		ascii, multibyte := f.AddBlock(), f.AddBlock()
+		g.parentBlock.CondBr(g.compare(goory.IntSle, lead, utf8Lengths[0].max), ascii, multibyte)
+		ascii.Ret(ascii.Cast(lead, goory.IntType(32)))
+		g.parentBlock = multibyte
+
+		// The lead byte determines the length, continuation bytes are not
+		// valid lead bytes
+		g.require(g.compare(goory.IntSge, lead, utf8Lengths[1].lead), invalid)
+		for size := 2; size <= len(utf8Lengths); size++ {
+			if size < len(utf8Lengths) {
+				decode, next := f.AddBlock(), f.AddBlock()
+				g.parentBlock.CondBr(g.compare(goory.IntSlt, lead, utf8Lengths[size].lead), decode, next)
+				g.parentBlock = decode
+				g.decode(data, length, lead, size, invalid)
+				g.parentBlock = next
+				continue
+			}
+			g.require(g.compare(goory.IntSlt, lead, 0xF8), invalid)
+			g.decode(data, length, lead, size, invalid)
+		}
+	})
+}
+
+// decode returns the rune encoded by the UTF-8 sequence of the size at the
+// start of the data, branching to invalid if it is not a valid encoding
+func (g *Irgen) decode(data, length, lead gooryvalues.Value, size int, invalid *goory.Block) {
+	g.require(g.compare(goory.IntSge, length, int64(size)), invalid)
+
+	value := g.parentBlock.Sub(lead, goory.Constant(goory.IntType(64), utf8Lengths[size-1].lead))
+	for i := 1; i < size; i++ {
+		bits := g.parentBlock.Sub(g.byteAt(data, i), goory.Constant(goory.IntType(64), 0x80))
+		continuation := g.parentBlock.Select(g.compare(goory.IntSge, bits, 0),
+			g.compare(goory.IntSlt, bits, 64), goory.Constant(goory.BoolType(), false))
+		g.require(continuation, invalid)
+
+		value = g.parentBlock.Mul(value, goory.Constant(goory.IntType(64), 64))
+		value = g.parentBlock.Add(value, bits)
+	}
+
+	// Overlong encodings, surrogates and runes past the last code point
+	g.require(g.compare(goory.IntSgt, value, utf8Lengths[size-2].max), invalid)
+	g.require(g.compare(goory.IntSle, value, 0x10FFFF), invalid)
+	if size == 3 {
+		g.require(g.parentBlock.Select(g.compare(goory.IntSlt, value, 0xD800),
+			goory.Constant(goory.BoolType(), true), g.compare(goory.IntSgt, value, 0xDFFF)), invalid)
+	}
+
+	g.parentBlock.Ret(g.parentBlock.Cast(value, goory.IntType(32)))
+}
+
+// byteAt loads the byte at the index of the data as an unsigned i64
+func (g *Irgen) byteAt(data gooryvalues.Value, index int) gooryvalues.Value {
+	ptr := g.parentBlock.Getelementptr(goory.IntType(8), data, goory.Constant(goory.IntType(64), index))
+	value := g.parentBlock.Cast(g.parentBlock.Load(ptr), goory.IntType(64))
+	return g.parentBlock.Select(g.compare(goory.IntSlt, value, 0),
+		g.parentBlock.Add(value, goory.Constant(goory.IntType(64), 256)), value)
+}
+
+// compare compares the i64 value with the constant
+func (g *Irgen) compare(predicate goory.IntPredicate, value gooryvalues.Value, constant int64) gooryvalues.Value {
+	return g.parentBlock.Icmp(predicate, value, goory.Constant(goory.IntType(64), constant))
+}
+
+// require continues in a new block if the condition is true, otherwise it
+// branches to the fail block
+func (g *Irgen) require(condition gooryvalues.Value, fail *goory.Block) {
+	ok := g.parentBlock.Function().AddBlock()
+	g.parentBlock.CondBr(condition, ok, fail)
+	g.parentBlock = ok
+}
@@ -0,0 +1,41 @@
+package irgen
+
+import (
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/lexer"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// structLiteralExp builds a struct value from the value of each field
+func (g *Irgen) structLiteralExp(node *ast.BraceLiteralExpression) gooryvalues.Value {
+	values := make([]gooryvalues.Value, len(node.Elements))
+	for i, element := range node.Elements {
+		values[i] = g.expression(element)
+	}
+
+	return g.aggregate(values...)
+}
+
+// fieldExp returns the value of a field, fields of varibles are loaded
+// directly instead of copying the whole struct
+func (g *Irgen) fieldExp(node *ast.FieldExpression) gooryvalues.Value {
+	if addressable(node.Expression) {
+		return g.parentBlock.Load(g.address(node))
+	}
+
+	return g.parentBlock.Extractvalue(g.expression(node.Expression), node.Index)
+}
+
+// addressable returns true if the expression refers to memory
+func addressable(node ast.Expression) bool {
+	switch node := node.(type) {
+	case *ast.IdentExpression, *ast.IndexExpression:
+		return true
+	case *ast.UnaryExpression:
+		return node.Operator.Type() == lexer.MUL
+	case *ast.FieldExpression:
+		return addressable(node.Expression)
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package irgen
+
+import (
+	"github.com/bongo227/Furlang/ast"
+	"github.com/bongo227/Furlang/types"
+	"github.com/bongo227/goory"
+	gtypes "github.com/bongo227/goory/types"
+	gooryvalues "github.com/bongo227/goory/value"
+)
+
+// tag returns a pointer to the tag of the union
+func (g *Irgen) tag(union gooryvalues.Value) gooryvalues.Value {
+	return g.parentBlock.Getelementptr(goory.IntType(32), union,
+		goory.Constant(goory.IntType(32), 0),
+		goory.Constant(goory.IntType(32), 0))
+}
+
+// fields returns pointers to the fields of the variant held by the union
+func (g *Irgen) fields(union gooryvalues.Value, typ *types.Union, variant *types.Variant) []gooryvalues.Value {
+	payload := g.parentBlock.Getelementptr(typ.Payload(), union,
+		goory.Constant(goory.IntType(32), 0),
+		goory.Constant(goory.IntType(32), 1))
+	payload = g.parentBlock.Cast(payload, gtypes.NewPointerType(variant.Tuple().Llvm()))
+
+	fields := make([]gooryvalues.Value, len(variant.Types))
+	for i, fieldType := range variant.Types {
+		fields[i] = g.parentBlock.Getelementptr(fieldType.Llvm(), payload,
+			goory.Constant(goory.IntType(32), 0),
+			goory.Constant(goory.IntType(32), i))
+	}
+
+	return fields
+}
+
+// variantExp builds a union holding the variant
+func (g *Irgen) variantExp(node *ast.VariantExpression) gooryvalues.Value {
+	alloc := g.parentBlock.Alloca(node.Type.Llvm())
+	g.parentBlock.Store(g.tag(alloc), goory.Constant(goory.IntType(32), node.Tag))
+
+	if node.Arguments != nil {
+		fields := g.fields(alloc, node.Type, node.Type.Variants()[node.Tag])
+		for i, arg := range node.Arguments.Elements {
+			g.parentBlock.Store(fields[i], g.expression(arg))
+		}
+	}
+
+	return g.parentBlock.Load(alloc)
+}
+
+func (g *Irgen) matchSmt(node *ast.MatchStatement) {
+	g.match(node.Value, node.Union, node.Clauses, func(clause *ast.MatchClause) {
+		g.block(clause.Body)
+	})
+}
+
+// matchExp stores the result of the clause that runs and loads it once
+// control rejoins
+func (g *Irgen) matchExp(node *ast.MatchExpression) gooryvalues.Value {
+	result := g.parentBlock.Alloca(node.Type.Llvm())
+	g.match(node.Value, node.Union, node.Clauses, func(clause *ast.MatchClause) {
+		g.parentBlock.Store(result, g.expression(clause.Result))
+	})
+
+	return g.parentBlock.Load(result)
+}
+
+// match switches on the tag of the union to the clause handling the variant,
+// body generates each clause with its bindings in scope
+func (g *Irgen) match(value ast.Expression, typ *types.Union, clauses []*ast.MatchClause,
+	body func(*ast.MatchClause)) {
+	function := g.parentBlock.Function()
+	endBlock := function.AddBlock()
+
+	// The bindings point into a copy so the value is evaluated once
+	union := g.expression(value)
+	alloc := g.parentBlock.Alloca(union.Type())
+	g.parentBlock.Store(alloc, union)
+	tag := g.parentBlock.Load(g.tag(alloc))
+
+	// Analysis checks every variant is handled so without a default clause the
+	// default block is unreachable
+	var defaultBlock *goory.Block
+	blocks := make([]*goory.Block, len(clauses))
+	for i, clause := range clauses {
+		blocks[i] = function.AddBlock()
+		if clause.IsDefault() {
+			defaultBlock = blocks[i]
+		}
+	}
+	if defaultBlock == nil {
+		defaultBlock = function.AddBlock()
+		defaultBlock.Unreachable()
+	}
+
+	sw := g.parentBlock.Switch(tag, defaultBlock)
+	for i, clause := range clauses {
+		if !clause.IsDefault() {
+			sw.AddCase(goory.Constant(goory.IntType(32), clause.Tag), blocks[i])
+		}
+	}
+
+	root := g.scope
+	for i, clause := range clauses {
+		g.parentBlock = blocks[i]
+		g.scope = root.Push()
+
+		if !clause.IsDefault() && len(clause.Bindings) > 0 {
+			fields := g.fields(alloc, typ, clause.Variant)
+			for j, binding := range clause.Bindings {
+				g.scope.AddVar(binding.Value.Value(), fields[j])
+			}
+		}
+
+		body(clause)
+		if !g.parentBlock.Terminated() {
+			g.parentBlock.Br(endBlock)
+		}
+	}
+
+	g.scope = root
+	g.parentBlock = endBlock
+}
@@ -61,7 +61,7 @@ func (l *Lexer) nextRune() error {
 		// Check for non-UTF8 character
 		if r >= utf8.RuneSelf {
 			// Decode rune
-			r, width := utf8.DecodeRune(l.source[l.readingOffset:])
+			r, width = utf8.DecodeRune(l.source[l.readingOffset:])
 
 			// Check encoding
 			if r == utf8.RuneError && width == 1 {
@@ -125,11 +125,30 @@ func asDigit(c rune) int {
 	return 16
 }
 
-// mantissa consumes a mantissa
-func (l *Lexer) mantissa(base int) {
-	for asDigit(l.currentRune) < base {
+// mantissa consumes digits of the base and underscores, it returns a bit set
+// with 1 if there were any digits and 2 if there were any separators. Decimal
+// digits too large for a smaller base are consumed and the offset of the first
+// is stored in invalid
+func (l *Lexer) mantissa(base int, invalid *int) (digsep int) {
+	limit := 10
+	if base > limit {
+		limit = base
+	}
+
+	for asDigit(l.currentRune) < limit || l.currentRune == '_' {
+		switch {
+		case l.currentRune == '_':
+			digsep |= 2
+		case asDigit(l.currentRune) >= base && *invalid < 0:
+			*invalid = l.offset
+			fallthrough
+		default:
+			digsep |= 1
+		}
 		l.nextRune()
 	}
+
+	return digsep
 }
 
 // ident consumes an identifyer and returns its string
@@ -203,21 +222,25 @@ func (l *Lexer) string() (string, error) {
 	offset := l.offset - 1
 
 	for {
-		// Newline of end of line
-		if l.currentRune == '\n' || l.currentRune < 0 {
+		ch := l.currentRune
+		if ch == '\n' || ch < 0 {
 			return "", l.newError("string literal not terminated")
 		}
 
-		l.nextRune()
+		if err := l.nextRune(); err != nil {
+			return "", err
+		}
 
 		// End of string
-		if l.currentRune == '"' {
+		if ch == '"' {
 			break
 		}
 
 		// Start of escape sequence
-		if l.currentRune == '\\' {
-			l.escape('"')
+		if ch == '\\' {
+			if err := l.escape('"'); err != nil {
+				return "", err
+			}
 		}
 	}
 
@@ -225,51 +248,182 @@ func (l *Lexer) string() (string, error) {
 	return string(l.source[offset:l.offset]), nil
 }
 
+// char consumes a rune literal and returns its source
+func (l *Lexer) char() (string, error) {
+	offset := l.offset - 1
+
+	n := 0
+	for {
+		ch := l.currentRune
+		if ch == '\n' || ch < 0 {
+			return "", l.newError("rune literal not terminated")
+		}
+
+		if err := l.nextRune(); err != nil {
+			return "", err
+		}
+
+		// End of rune
+		if ch == '\'' {
+			break
+		}
+
+		n++
+
+		// Start of escape sequence
+		if ch == '\\' {
+			if err := l.escape('\''); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if n != 1 {
+		return "", l.newError("illegal rune literal")
+	}
+
+	return string(l.source[offset:l.offset]), nil
+}
+
+// number consumes an integer or float literal. Integers may have a 0x, 0o or
+// 0b prefix (or a leading 0 for octal), floats may have an exponent and digits
+// may be separated by underscores
 func (l *Lexer) number() (TokenType, string, error) {
 	offset := l.offset
 	tok := INT
 
+	base, prefix := 10, rune(0)
+	digsep := 0
+	invalid := -1
+
+	// Integer part
 	if l.currentRune == '0' {
-		// int or float
-		offset := l.offset
 		l.nextRune()
-		if l.currentRune == 'x' || l.currentRune == 'X' {
-			// hex
+		switch lower(l.currentRune) {
+		case 'x':
 			l.nextRune()
-			l.mantissa(16)
-			if l.offset-offset <= 2 {
-				return ILLEGAL, "", l.newError("Illegal hex number")
-			}
-		} else {
-			// assume octal
-			octal := true
-			l.mantissa(8)
-			if l.currentRune == '8' || l.currentRune == '9' {
-				// not an octal
-				octal = false
-				l.mantissa(10)
-			}
-			if l.currentRune == '.' {
-				goto fraction
-			}
-			if !octal {
-				return ILLEGAL, "", l.newError("Illegal octal number")
-			}
+			base, prefix = 16, 'x'
+		case 'o':
+			l.nextRune()
+			base, prefix = 8, 'o'
+		case 'b':
+			l.nextRune()
+			base, prefix = 2, 'b'
+		default:
+			base, prefix = 8, '0'
+			digsep = 1 // The leading 0 is a digit
 		}
-		goto exit
 	}
+	digsep |= l.mantissa(base, &invalid)
 
-	l.mantissa(10)
-
-fraction:
+	// Fractional part
 	if l.currentRune == '.' {
 		tok = FLOAT
+		if prefix == 'o' || prefix == 'b' {
+			return ILLEGAL, "", l.newError("invalid radix point in " + literalName(prefix))
+		}
+		l.nextRune()
+		digsep |= l.mantissa(base, &invalid)
+	}
+
+	if digsep&1 == 0 {
+		return ILLEGAL, "", l.newError(literalName(prefix) + " has no digits")
+	}
+
+	// Exponent
+	if exponent := lower(l.currentRune); exponent == 'e' || exponent == 'p' {
+		switch {
+		case exponent == 'e' && prefix != 0 && prefix != '0':
+			return ILLEGAL, "", l.newError(fmt.Sprintf("%q exponent requires decimal mantissa", l.currentRune))
+		case exponent == 'p' && prefix != 'x':
+			return ILLEGAL, "", l.newError(fmt.Sprintf("%q exponent requires hexadecimal mantissa", l.currentRune))
+		}
+
 		l.nextRune()
-		l.mantissa(10)
+		tok = FLOAT
+		if l.currentRune == '+' || l.currentRune == '-' {
+			l.nextRune()
+		}
+
+		ds := l.mantissa(10, &invalid)
+		digsep |= ds
+		if ds&1 == 0 {
+			return ILLEGAL, "", l.newError("exponent has no digits")
+		}
+	} else if prefix == 'x' && tok == FLOAT {
+		return ILLEGAL, "", l.newError("hexadecimal mantissa requires a 'p' exponent")
 	}
 
-exit:
-	return tok, string(l.source[offset:l.offset]), nil
+	// Digits after a leading 0 are only octal if the literal is an integer
+	if tok == INT && invalid >= 0 {
+		return ILLEGAL, "", l.newError(fmt.Sprintf("invalid digit %q in %s",
+			l.source[invalid], literalName(prefix)))
+	}
+
+	value := string(l.source[offset:l.offset])
+	if digsep&2 != 0 && invalidSeparator(value) {
+		return ILLEGAL, "", l.newError("'_' must separate successive digits")
+	}
+
+	return tok, value, nil
+}
+
+// literalName returns the name of the literal with the prefix for errors
+func literalName(prefix rune) string {
+	switch prefix {
+	case 'x':
+		return "hexadecimal literal"
+	case 'o', '0':
+		return "octal literal"
+	case 'b':
+		return "binary literal"
+	}
+
+	return "decimal literal"
+}
+
+// invalidSeparator returns true if an underscore in the literal doesnt
+// separate two digits, the base prefix counts as a digit
+func invalidSeparator(literal string) bool {
+	hex := false
+	previous := '.' // '_', '0' for any digit or '.' for anything else
+
+	i := 0
+	if len(literal) >= 2 && literal[0] == '0' {
+		switch lower(rune(literal[1])) {
+		case 'x':
+			hex = true
+			fallthrough
+		case 'o', 'b':
+			previous = '0'
+			i = 2
+		}
+	}
+
+	for ; i < len(literal); i++ {
+		ch := rune(literal[i])
+		switch {
+		case ch == '_':
+			if previous != '0' {
+				return true
+			}
+			previous = '_'
+		case asDigit(ch) < 10 || hex && asDigit(ch) < 16:
+			previous = '0'
+		default:
+			if previous == '_' {
+				return true
+			}
+			previous = '.'
+		}
+	}
+
+	return previous == '_'
+}
+
+// lower returns the lower case of an ascii letter
+func lower(ch rune) rune {
+	return ('a' - 'A') | ch
 }
 
 // switch helper functions deside between 2-4 runes in the case of multi symbol runes
@@ -381,16 +535,23 @@ func (l *Lexer) Lex() ([]Token, error) {
 				}
 				tok.value = value
 				l.insertSemi = true
+			case '\'':
+				tok.typ = CHAR
+				value, err := l.char()
+				if err != nil {
+					return nil, err
+				}
+				tok.value = value
+				l.insertSemi = true
 			case ':':
 				tok.typ = l.switch3(COLON, DEFINE, ':', DOUBLE_COLON)
 			case '.':
+				tok.typ = PERIOD
 				if l.currentRune == '.' {
 					l.nextRune()
 					if l.currentRune == '.' {
 						l.nextRune()
 						tok.typ = ELLIPSIS
-					} else {
-						tok.typ = PERIOD
 					}
 				}
 			case ',':
@@ -453,7 +614,12 @@ func (l *Lexer) Lex() ([]Token, error) {
 					tok.typ = l.switch3(AND, AND_ASSIGN, '&', LAND)
 				}
 			case '|':
-				tok.typ = l.switch3(OR, OR_ASSIGN, '|', LOR)
+				if l.currentRune == '>' {
+					l.nextRune()
+					tok.typ = PIPE
+				} else {
+					tok.typ = l.switch3(OR, OR_ASSIGN, '|', LOR)
+				}
 			default:
 				if l.currentRune == 0xFEFF {
 					return nil, l.newError(fmt.Sprintf("illegal character %#U", l.currentRune))
@@ -26,6 +26,13 @@ func TestLex(t *testing.T) {
 				Token{SEMICOLON, "\n", 1, 8},
 			},
 		},
+		{
+			input: `café`,
+			expected: []Token{
+				Token{IDENT, "café", 1, 1},
+				Token{SEMICOLON, "\n", 1, 6},
+			},
+		},
 		{
 			input: `123`,
 			expected: []Token{
@@ -49,6 +56,28 @@ func TestLex(t *testing.T) {
 				Token{SEMICOLON, "\n", 1, 8},
 			},
 		},
+		{
+			input: `0xFF 0o17 0b1010 0755 1_000_000`,
+			expected: []Token{
+				Token{INT, "0xFF", 1, 1},
+				Token{INT, "0o17", 1, 6},
+				Token{INT, "0b1010", 1, 11},
+				Token{INT, "0755", 1, 18},
+				Token{INT, "1_000_000", 1, 23},
+				Token{SEMICOLON, "\n", 1, 32},
+			},
+		},
+		{
+			input: `1e9 2.5E-3 0x1p-2 0x1.8P+1 09.5`,
+			expected: []Token{
+				Token{FLOAT, "1e9", 1, 1},
+				Token{FLOAT, "2.5E-3", 1, 5},
+				Token{FLOAT, "0x1p-2", 1, 12},
+				Token{FLOAT, "0x1.8P+1", 1, 19},
+				Token{FLOAT, "09.5", 1, 28},
+				Token{SEMICOLON, "\n", 1, 32},
+			},
+		},
 		{
 			input: `int a -> int`,
 			expected: []Token{
@@ -59,6 +88,29 @@ func TestLex(t *testing.T) {
 				Token{SEMICOLON, "\n", 1, 13},
 			},
 		},
+		{
+			input: `x |> f >> g`,
+			expected: []Token{
+				Token{IDENT, "x", 1, 1},
+				Token{PIPE, "", 1, 3},
+				Token{IDENT, "f", 1, 6},
+				Token{SHR, "", 1, 8},
+				Token{IDENT, "g", 1, 11},
+				Token{SEMICOLON, "\n", 1, 12},
+			},
+		},
+		{
+			input: `Shape.Circle(r)`,
+			expected: []Token{
+				Token{IDENT, "Shape", 1, 1},
+				Token{PERIOD, "", 1, 6},
+				Token{IDENT, "Circle", 1, 7},
+				Token{LPAREN, "", 1, 13},
+				Token{IDENT, "r", 1, 14},
+				Token{RPAREN, "", 1, 15},
+				Token{SEMICOLON, "\n", 1, 16},
+			},
+		},
 		{
 			input: `1
 2
@@ -72,6 +124,34 @@ func TestLex(t *testing.T) {
 				Token{SEMICOLON, "\n", 3, 2},
 			},
 		},
+		{
+			input: `'a' '\n' '\''`,
+			expected: []Token{
+				Token{CHAR, "'a'", 1, 1},
+				Token{CHAR, "'\\n'", 1, 5},
+				Token{CHAR, "'\\''", 1, 10},
+				Token{SEMICOLON, "\n", 1, 14},
+			},
+		},
+		{
+			input: `'\x41' '\u00e9' '\U0001F600' 'é'`,
+			expected: []Token{
+				Token{CHAR, "'\\x41'", 1, 1},
+				Token{CHAR, "'\\u00e9'", 1, 8},
+				Token{CHAR, "'\\U0001F600'", 1, 17},
+				Token{CHAR, "'é'", 1, 30},
+				Token{SEMICOLON, "\n", 1, 34},
+			},
+		},
+		{
+			input: `"" "a\"b" "é\u00e9"`,
+			expected: []Token{
+				Token{STRING, `""`, 1, 1},
+				Token{STRING, `"a\"b"`, 1, 4},
+				Token{STRING, `"é\u00e9"`, 1, 11},
+				Token{SEMICOLON, "\n", 1, 21},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -95,3 +175,61 @@ func TestLex(t *testing.T) {
 		}
 	}
 }
+
+func TestLexRuneErrors(t *testing.T) {
+	cases := []string{
+		`''`,
+		`'ab'`,
+		`'a`,
+		`'\q'`,
+		`'\u12'`,
+		`'\uD800'`,
+		"'\xff'",
+	}
+
+	for _, c := range cases {
+		if _, err := NewLexer([]byte(c)).Lex(); err == nil {
+			t.Errorf("Expected %s to cause a lexer error", c)
+		}
+	}
+}
+
+func TestLexStringErrors(t *testing.T) {
+	cases := []string{
+		`"abc`,
+		"\"a\nb\"",
+		`"\q"`,
+		`"\u12"`,
+	}
+
+	for _, c := range cases {
+		if _, err := NewLexer([]byte(c)).Lex(); err == nil {
+			t.Errorf("Expected %s to cause a lexer error", c)
+		}
+	}
+}
+
+func TestLexNumberErrors(t *testing.T) {
+	cases := []string{
+		`0x`,
+		`0b102`,
+		`0o8`,
+		`089`,
+		`0b1.0`,
+		`0x1.8`,
+		`0x1e3p`,
+		`1e`,
+		`1e+`,
+		`0b1p3`,
+		`1__000`,
+		`1_000_`,
+		`0x_FF_`,
+		`1_.5`,
+	}
+
+	for _, c := range cases {
+		if _, err := NewLexer([]byte(c)).Lex(); err == nil {
+			t.Errorf("Expected %s to cause a lexer error", c)
+		}
+	}
+}
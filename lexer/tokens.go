@@ -63,6 +63,7 @@ const (
 	LAND
 	LOR
 	ARROW
+	PIPE
 	INC
 	DEC
 	EQL
@@ -97,12 +98,15 @@ const (
 	DEFAULT
 	DEFER
 	ELSE
+	ENUM
 	FALLTHROUGH
 	FOR
 	FUNC
 	PROC
 	IF
 	IMPORT
+	INTERFACE
+	MATCH
 	RETURN
 	SELECT
 	STRUCT
@@ -161,6 +165,7 @@ var tokens = [...]string{
 	LAND:  "&&",
 	LOR:   "||",
 	ARROW: "->",
+	PIPE:  "|>",
 	INC:   "++",
 	DEC:   "--",
 
@@ -197,13 +202,16 @@ var tokens = [...]string{
 	DEFAULT:     "default",
 	DEFER:       "defer",
 	ELSE:        "else",
+	ENUM:        "enum",
 	FALLTHROUGH: "fallthrough",
 	FOR:         "for",
 
-	FUNC:   "func",
-	PROC:   "proc",
-	IF:     "if",
-	IMPORT: "import",
+	FUNC:      "func",
+	PROC:      "proc",
+	IF:        "if",
+	IMPORT:    "import",
+	INTERFACE: "interface",
+	MATCH:     "match",
 
 	RETURN: "return",
 
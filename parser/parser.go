@@ -18,6 +18,20 @@ type Parser struct {
 	tokens []lexer.Token
 	scope  *ast.Scope
 	index  int
+
+	// typeParameters are the type parameters of the generic procedure being parsed
+	typeParameters map[string]*types.Parameter
+
+	// namedTypes are the types declared in the file, aliases holds the index
+	// of the first token of the type each alias refers to until it is resolved
+	namedTypes map[string]types.Type
+	aliases    map[string]int
+
+	// lengths are the array types whose length is a constant expression
+	lengths []*ast.ArrayLength
+
+	// procedures are the names of the procedures declared in the file
+	procedures map[string]bool
 }
 
 // Error represents an error in the parser package
@@ -68,11 +82,14 @@ func (e *InternalError) Error() string {
 // NewParser creates a new parser, if scope is false all block scopes will be nil
 func NewParser(tokens []lexer.Token, scope bool) *Parser {
 	p := &Parser{
-		tokens: tokens,
+		tokens:     tokens,
+		namedTypes: make(map[string]types.Type),
+		aliases:    make(map[string]int),
+		procedures: make(map[string]bool),
 	}
 
 	if scope {
-		p.scope = ast.NewScope()
+		p.scope = ast.Universe.Enter()
 	}
 
 	return p
@@ -144,7 +161,7 @@ func (p *Parser) accept(typ lexer.TokenType) (lexer.Token, bool) {
 
 func bindingPower(token lexer.Token) int {
 	switch token.Type() {
-	case lexer.LPAREN, lexer.LBRACK:
+	case lexer.LPAREN, lexer.LBRACK, lexer.PERIOD:
 		return 150
 	case lexer.ADD, lexer.SUB:
 		return 110
@@ -153,8 +170,12 @@ func bindingPower(token lexer.Token) int {
 	case lexer.LSS, lexer.LEQ, lexer.GTR, lexer.GEQ,
 		lexer.EQL, lexer.NEQ:
 		return 60
+	case lexer.SHR:
+		return 140
 	case lexer.LBRACE:
 		return 20
+	case lexer.PIPE:
+		return 10
 	}
 
 	return 0
@@ -164,17 +185,26 @@ func (p *Parser) nud(token lexer.Token) ast.Expression {
 	// TODO: break this down into a token interace that has a nud method.
 	switch token.Type() {
 	case lexer.IDENT:
+		// Struct literal
+		if typ, ok := p.namedTypes[token.Value()].(*types.Struct); ok && p.token().Type() == lexer.LBRACE {
+			return p.structLiteral(typ)
+		}
+
 		return &ast.IdentExpression{
 			Value: token,
 		}
-	case lexer.INT, lexer.FLOAT:
+	case lexer.INT, lexer.FLOAT, lexer.CHAR, lexer.STRING:
 		return &ast.LiteralExpression{
 			Value: token,
 		}
-	case lexer.ADD, lexer.SUB:
+	case lexer.PROC:
+		return p.lambda(token)
+	case lexer.MATCH:
+		return p.matchExp(token)
+	case lexer.ADD, lexer.SUB, lexer.AND, lexer.MUL:
 		return &ast.UnaryExpression{
 			Operator:   token,
-			Expression: p.expression(100),
+			Expression: p.expression(130),
 		}
 	case lexer.LPAREN:
 		if rparen, ok := p.accept(lexer.RPAREN); ok {
@@ -212,7 +242,7 @@ func (p *Parser) led(token lexer.Token, tree ast.Expression) ast.Expression {
 	switch token.Type() {
 	case lexer.ADD, lexer.SUB, lexer.MUL, lexer.QUO,
 		lexer.LSS, lexer.LEQ, lexer.GTR, lexer.GEQ,
-		lexer.EQL, lexer.NEQ, lexer.REM:
+		lexer.EQL, lexer.NEQ, lexer.REM, lexer.SHR, lexer.PIPE:
 
 		log.Printf("Binding with power %d", bindingPower(token))
 		e := p.expression(bindingPower(token))
@@ -224,6 +254,13 @@ func (p *Parser) led(token lexer.Token, tree ast.Expression) ast.Expression {
 	case lexer.LPAREN:
 		elements := []ast.Expression{}
 		ok := p.token().Type() != lexer.RPAREN
+
+		// Allocation builtins take a type as their first argument
+		if ident, isIdent := tree.(*ast.IdentExpression); isIdent && ok && p.allocation(ident.Value.Value()) {
+			elements = append(elements, &ast.TypeExpression{Type: p.typ()})
+			_, ok = p.accept(lexer.COMMA)
+		}
+
 		for ok {
 			elements = append(elements, p.expression(0))
 			_, ok = p.accept(lexer.COMMA)
@@ -237,6 +274,14 @@ func (p *Parser) led(token lexer.Token, tree ast.Expression) ast.Expression {
 				RightParen: p.expect(lexer.RPAREN),
 			},
 		}
+	case lexer.PERIOD:
+		return &ast.SelectorExpression{
+			Expression: tree,
+			Period:     token,
+			Selection: &ast.IdentExpression{
+				Value: p.expect(lexer.IDENT),
+			},
+		}
 	case lexer.LBRACK:
 		return &ast.IndexExpression{
 			Expression: tree,
@@ -246,31 +291,27 @@ func (p *Parser) led(token lexer.Token, tree ast.Expression) ast.Expression {
 		}
 
 	case lexer.LBRACE:
-		indexExp, ok := tree.(*ast.IndexExpression)
-		if !ok {
+		// Convert index expressions into the array type, the innermost index
+		// is the last length
+		var lengths []ast.Expression
+		base := tree
+		for indexExp, ok := base.(*ast.IndexExpression); ok; indexExp, ok = base.(*ast.IndexExpression) {
+			lengths = append(lengths, indexExp.Index)
+			base = indexExp.Expression
+		}
+
+		ident, ok := base.(*ast.IdentExpression)
+		if !ok || len(lengths) == 0 {
 			log.Fatalf("Expected left hand side of { to be type \"*ast.IndexExpression\", got %q",
 				reflect.TypeOf(tree).String())
 		}
 
-		// Convert index expression into array type
-		typeName := indexExp.Expression.(*ast.IdentExpression).Value.Value()
-		arraySize := indexExp.Index.(*ast.LiteralExpression).Value.Value()
-		size, _ := strconv.Atoi(arraySize)
-		arrayType := types.NewArray(types.GetType(typeName), int64(size))
-
-		elements := []ast.Expression{}
-
-		for p.token().Type() != lexer.RBRACE {
-			elements = append(elements, p.expression(0))
-			p.accept(lexer.COMMA)
+		typ := p.lookupType(ident.Value.Value())
+		for _, length := range lengths {
+			typ = p.arrayType(typ, length)
 		}
 
-		return &ast.BraceLiteralExpression{
-			Type:       arrayType,
-			LeftBrace:  token,
-			Elements:   elements,
-			RightBrace: p.expect(lexer.RBRACE),
-		}
+		return p.arrayLiteral(typ.(*types.Array), token)
 	}
 
 	panic("led Undefined for token type: " + p.token().Type().String())
@@ -302,16 +343,116 @@ func (p *Parser) assigment() *ast.AssignmentStatement {
 	log.Printf(p.token().String())
 
 	return &ast.AssignmentStatement{
-		Left:   p.expression(0),
+		Left:   p.expressionList(),
+		Assign: p.expect(lexer.ASSIGN),
+		Right:  p.expressionList(),
+	}
+}
+
+// selectorSmt parses a statement starting with a selector, either a method
+// call or an assignment to a field
+func (p *Parser) selectorSmt() ast.Statement {
+	left := p.expression(0)
+	if _, ok := left.(*ast.CallExpression); ok {
+		return &ast.ExpressionStatement{
+			Expression: left,
+		}
+	}
+
+	return &ast.AssignmentStatement{
+		Left:   left,
 		Assign: p.expect(lexer.ASSIGN),
-		Right:  p.expression(0),
+		Right:  p.expressionList(),
+	}
+}
+
+func (p *Parser) deferSmt() *ast.DeferStatement {
+	deferToken := p.expect(lexer.DEFER)
+
+	call, ok := p.expression(0).(*ast.CallExpression)
+	if !ok {
+		panic(p.newError("Expected procedure call after defer"))
+	}
+
+	return &ast.DeferStatement{
+		Defer: deferToken,
+		Call:  call,
 	}
 }
 
 func (p *Parser) returnSmt() *ast.ReturnStatement {
+	returnToken := p.expect(lexer.RETURN)
+
+	// Bare return
+	switch p.token().Type() {
+	case lexer.SEMICOLON, lexer.RBRACE:
+		return &ast.ReturnStatement{
+			Return: returnToken,
+		}
+	}
+
 	return &ast.ReturnStatement{
-		Return: p.expect(lexer.RETURN),
-		Result: p.expression(0),
+		Return: returnToken,
+		Result: p.expressionList(),
+	}
+}
+
+// expressionList parses one or more comma seperated expressions, multiple
+// expressions are returned as a paren literal
+func (p *Parser) expressionList() ast.Expression {
+	first := p.expression(0)
+	if p.token().Type() != lexer.COMMA {
+		return first
+	}
+
+	elements := []ast.Expression{first}
+	for _, ok := p.accept(lexer.COMMA); ok; _, ok = p.accept(lexer.COMMA) {
+		elements = append(elements, p.expression(0))
+	}
+
+	return &ast.ParenLiteralExpression{
+		Elements: elements,
+	}
+}
+
+// destructure parses a statement in the form: expression, ... := expression
+// or expression, ... = expression, ...
+func (p *Parser) destructure() ast.Statement {
+	left := p.expressionList().(*ast.ParenLiteralExpression)
+
+	if define, ok := p.accept(lexer.DEFINE); ok {
+		declarations := make([]*ast.VaribleDeclaration, len(left.Elements))
+		for i, element := range left.Elements {
+			name, ok := element.(*ast.IdentExpression)
+			if !ok {
+				panic(p.newError(fmt.Sprintf("Expected identifier on left side of :=, got %s",
+					reflect.TypeOf(element).String())))
+			}
+
+			declarations[i] = &ast.VaribleDeclaration{
+				Name: name,
+			}
+		}
+
+		tupleDcl := &ast.TupleDeclaration{
+			Declarations: declarations,
+			Define:       define,
+			Value:        p.expressionList(),
+		}
+
+		for _, dcl := range declarations {
+			p.insertScope(dcl.Name.Value.Value(), dcl)
+		}
+
+		return &ast.DeclareStatement{
+			Statement: tupleDcl,
+		}
+	}
+
+	return &ast.AssignmentStatement{
+		Left:   left,
+		Assign: p.expect(lexer.ASSIGN),
+		Right:  p.expressionList(),
 	}
 }
 
@@ -374,6 +515,174 @@ func (p *Parser) forSmt() *ast.ForStatement {
 	}
 }
 
+func (p *Parser) switchSmt() *ast.SwitchStatement {
+	switchToken := p.expect(lexer.SWITCH)
+
+	// Tagless switches go straight into the brace
+	var tag ast.Expression
+	if p.token().Type() != lexer.LBRACE {
+		tag = p.expression(0)
+	}
+
+	lbrace := p.expect(lexer.LBRACE)
+
+	clauses := []*ast.CaseClause{}
+	rbrace, ok := p.accept(lexer.RBRACE)
+	for !ok {
+		clauses = append(clauses, p.caseClause())
+		rbrace, ok = p.accept(lexer.RBRACE)
+	}
+
+	return &ast.SwitchStatement{
+		Switch:     switchToken,
+		Tag:        tag,
+		LeftBrace:  lbrace,
+		Clauses:    clauses,
+		RightBrace: rbrace,
+	}
+}
+
+func (p *Parser) caseClause() *ast.CaseClause {
+	var caseToken lexer.Token
+	var expressions []ast.Expression
+
+	if defaultToken, ok := p.accept(lexer.DEFAULT); ok {
+		caseToken = defaultToken
+	} else {
+		caseToken = p.expect(lexer.CASE)
+		expressions = append(expressions, p.expression(0))
+		for _, ok := p.accept(lexer.COMMA); ok; _, ok = p.accept(lexer.COMMA) {
+			expressions = append(expressions, p.expression(0))
+		}
+	}
+
+	colon := p.expect(lexer.COLON)
+	p.accept(lexer.SEMICOLON)
+
+	// Each clause body is an implicit block ending at the next clause
+	p.enterScope()
+	statements := []ast.Statement{}
+	for !p.clauseEnd() {
+		statements = append(statements, p.statement())
+		p.expect(lexer.SEMICOLON)
+	}
+	blockScope := p.scope
+	p.exitScope()
+
+	return &ast.CaseClause{
+		Case:        caseToken,
+		Expressions: expressions,
+		Colon:       colon,
+		Body: &ast.BlockStatement{
+			Scope:      blockScope,
+			Statements: statements,
+		},
+	}
+}
+
+// matchSmt parses a match statement, each clause body is a list of statements
+func (p *Parser) matchSmt() *ast.MatchStatement {
+	match := p.expect(lexer.MATCH)
+	value, lbrace, clauses, rbrace := p.matchBody(false)
+
+	return &ast.MatchStatement{
+		Match:      match,
+		Value:      value,
+		LeftBrace:  lbrace,
+		Clauses:    clauses,
+		RightBrace: rbrace,
+	}
+}
+
+// matchExp parses a match expression, each clause has a single expression as
+// its result. The match keyword has been consumed.
+func (p *Parser) matchExp(match lexer.Token) *ast.MatchExpression {
+	value, lbrace, clauses, rbrace := p.matchBody(true)
+
+	return &ast.MatchExpression{
+		Match:      match,
+		Value:      value,
+		LeftBrace:  lbrace,
+		Clauses:    clauses,
+		RightBrace: rbrace,
+	}
+}
+
+// matchBody parses the value and clauses of a match
+func (p *Parser) matchBody(expression bool) (ast.Expression, lexer.Token, []*ast.MatchClause, lexer.Token) {
+	value := p.expression(0)
+	lbrace := p.expect(lexer.LBRACE)
+	p.accept(lexer.SEMICOLON)
+
+	clauses := []*ast.MatchClause{}
+	rbrace, ok := p.accept(lexer.RBRACE)
+	for !ok {
+		clauses = append(clauses, p.matchClause(expression))
+		rbrace, ok = p.accept(lexer.RBRACE)
+	}
+
+	return value, lbrace, clauses, rbrace
+}
+
+// matchClause parses a clause in the form: case ident(ident, ...): body
+// or default: body
+func (p *Parser) matchClause(expression bool) *ast.MatchClause {
+	clause := &ast.MatchClause{}
+
+	if defaultToken, ok := p.accept(lexer.DEFAULT); ok {
+		clause.Case = defaultToken
+	} else {
+		clause.Case = p.expect(lexer.CASE)
+		clause.Name = &ast.IdentExpression{Value: p.expect(lexer.IDENT)}
+		if _, ok := p.accept(lexer.LPAREN); ok {
+			for ok := p.token().Type() != lexer.RPAREN; ok; _, ok = p.accept(lexer.COMMA) {
+				clause.Bindings = append(clause.Bindings, &ast.IdentExpression{
+					Value: p.expect(lexer.IDENT),
+				})
+			}
+			p.expect(lexer.RPAREN)
+		}
+	}
+
+	clause.Colon = p.expect(lexer.COLON)
+	p.accept(lexer.SEMICOLON)
+
+	// The bindings are typed by analysis once the variant is known
+	p.enterScope()
+	for _, binding := range clause.Bindings {
+		p.insertScope(binding.Value.Value(), &ast.VaribleDeclaration{Name: binding})
+	}
+
+	statements := []ast.Statement{}
+	if expression {
+		clause.Result = p.expression(0)
+		p.accept(lexer.SEMICOLON)
+	} else {
+		for !p.clauseEnd() {
+			statements = append(statements, p.statement())
+			p.expect(lexer.SEMICOLON)
+		}
+	}
+
+	clause.Body = &ast.BlockStatement{
+		Scope:      p.scope,
+		Statements: statements,
+	}
+	p.exitScope()
+
+	return clause
+}
+
+// clauseEnd returns true if the current token ends a case clause body
+func (p *Parser) clauseEnd() bool {
+	switch p.token().Type() {
+	case lexer.CASE, lexer.DEFAULT, lexer.RBRACE:
+		return true
+	}
+
+	return false
+}
+
 func (p *Parser) incrementSmt() *ast.AssignmentStatement {
 	exp := p.expression(0)
 
@@ -426,6 +735,22 @@ func (p *Parser) incrementSmt() *ast.AssignmentStatement {
 	}
 }
 
+// isIncrement returns true if the expression at the current token is followed by
+// an increment operator, the parser position is left unchanged
+func (p *Parser) isIncrement() bool {
+	index := p.index
+	defer func() { p.index = index }()
+
+	p.expression(0)
+	switch p.token().Type() {
+	case lexer.INC, lexer.DEC, lexer.ADD_ASSIGN, lexer.SUB_ASSIGN, lexer.MUL_ASSIGN,
+		lexer.QUO_ASSIGN, lexer.REM_ASSIGN:
+		return true
+	}
+
+	return false
+}
+
 func (p *Parser) statement() ast.Statement {
 	switch p.token().Type() {
 	case lexer.RETURN:
@@ -436,16 +761,49 @@ func (p *Parser) statement() ast.Statement {
 		return p.ifSmt()
 	case lexer.FOR:
 		return p.forSmt()
+	case lexer.SWITCH:
+		return p.switchSmt()
+	case lexer.MATCH:
+		return p.matchSmt()
+	case lexer.FALLTHROUGH:
+		return &ast.FallthroughStatement{
+			Fallthrough: p.expect(lexer.FALLTHROUGH),
+		}
+	case lexer.DEFER:
+		return p.deferSmt()
+	case lexer.CONST:
+		return &ast.DeclareStatement{
+			Statement: p.constantDcl(),
+		}
+	case lexer.PROC:
+		// Function varible declaration
+		return &ast.DeclareStatement{
+			Statement: p.varibleDcl(),
+		}
 	// TODO: covert this into pratt pass
 	case lexer.IDENT:
 		// Check for varible declaration
-		if types.GetType(p.token().Value()) != nil || p.peek().Type() == lexer.DEFINE {
+		if p.isType(p.token().Value()) || p.peek().Type() == lexer.DEFINE {
 			return &ast.DeclareStatement{
 				Statement: p.varibleDcl(),
 			}
 		}
 
 		switch p.peek().Type() {
+		// Destructuring declaration or assignment
+		case lexer.COMMA:
+			return p.destructure()
+
+		// Method call or field assignment
+		case lexer.PERIOD:
+			return p.selectorSmt()
+
+		// Call statement
+		case lexer.LPAREN:
+			return &ast.ExpressionStatement{
+				Expression: p.expression(0),
+			}
+
 		// Increment statement
 		case lexer.INC, lexer.DEC, lexer.ADD_ASSIGN, lexer.SUB_ASSIGN, lexer.MUL_ASSIGN,
 			lexer.QUO_ASSIGN, lexer.REM_ASSIGN:
@@ -453,9 +811,26 @@ func (p *Parser) statement() ast.Statement {
 
 		// Assignment statment
 		default:
+			if p.isIncrement() {
+				return p.incrementSmt()
+			}
 			return p.assigment()
 		}
 
+	case lexer.MUL:
+		// Pointer varible declaration
+		if p.isType(p.peek().Value()) {
+			return &ast.DeclareStatement{
+				Statement: p.varibleDcl(),
+			}
+		}
+
+		// Assignment through a pointer
+		if p.isIncrement() {
+			return p.incrementSmt()
+		}
+		return p.assigment()
+
 	default:
 		log.Fatalf("Unkown statement starting with %q", p.token().Type().String())
 		return nil
@@ -463,33 +838,178 @@ func (p *Parser) statement() ast.Statement {
 }
 
 func (p *Parser) functionDcl() *ast.FunctionDeclaration {
-	p.expect(lexer.PROC)
+	// Pure functions are declared with func instead of proc
+	_, pure := p.accept(lexer.FUNC)
+	if !pure {
+		p.expect(lexer.PROC)
+	}
+
+	// Methods declare their receiver before the name
+	var receiver *ast.ArgumentDeclaration
+	if _, ok := p.accept(lexer.LPAREN); ok {
+		receiver = &ast.ArgumentDeclaration{Type: p.typ()}
+		receiver.Name = &ast.IdentExpression{Value: p.expect(lexer.IDENT)}
+		p.expect(lexer.RPAREN)
+	}
 
 	// Parse function name
 	name := &ast.IdentExpression{
 		Value: p.expect(lexer.IDENT),
 	}
 
+	// Generic procedures declare type parameters after the name
+	var typeParameters []*types.Parameter
+	if _, ok := p.accept(lexer.LSS); ok {
+		typeParameters = p.typeParameterList()
+		defer func() { p.typeParameters = nil }()
+	}
+
 	colon := p.expect(lexer.DOUBLE_COLON)
+	arguments, returnTyp, block := p.signature()
+	p.expect(lexer.SEMICOLON)
 
-	// Parse function arguments
-	arguments := []*ast.ArgumentDeclaration{}
-	_, ok := p.accept(lexer.ARROW)
-	for !ok {
-		typ := p.typ()
-		name := p.expect(lexer.IDENT)
-		ident := &ast.IdentExpression{Value: name}
-		arguments = append(arguments, &ast.ArgumentDeclaration{
-			Name: ident,
-			Type: typ,
-		})
+	funcDcl := &ast.FunctionDeclaration{
+		Name:        name,
+		DoubleColon: colon,
+		Arguments:   arguments,
+		Return:      returnTyp,
+		Body:        block,
+		Pure:        pure,
+		Receiver:    receiver,
 
-		_, ok = p.accept(lexer.ARROW)
-		if !ok {
-			p.expect(lexer.COMMA)
+		TypeParameters: typeParameters,
+	}
+
+	// Methods are found through the type of their receiver
+	if receiver != nil {
+		if block.Scope != nil {
+			block.Scope.Insert(receiver.Name.Value.Value(), &ast.VaribleDeclaration{
+				Name: receiver.Name,
+				Type: receiver.Type,
+			})
 		}
+		return funcDcl
 	}
 
+	// Insert function into root scope
+	p.insertScope(name.Value.Value(), funcDcl)
+
+	return funcDcl
+}
+
+// typeParameterList parses the type parameters of a generic procedure in the
+// form: <T, U: numeric>
+func (p *Parser) typeParameterList() []*types.Parameter {
+	p.typeParameters = make(map[string]*types.Parameter)
+
+	parameters := []*types.Parameter{}
+	for ok := true; ok; _, ok = p.accept(lexer.COMMA) {
+		name := p.expect(lexer.IDENT).Value()
+
+		parameter := types.NewParameter(name, 0)
+		if _, hasConstraint := p.accept(lexer.COLON); hasConstraint {
+			constraintName := p.expect(lexer.IDENT).Value()
+			if iface, ok := p.namedTypes[constraintName].(*types.Interface); ok {
+				parameter = types.NewInterfaceParameter(name, iface)
+			} else if constraint, ok := types.GetConstraint(constraintName); ok {
+				parameter = types.NewParameter(name, constraint)
+			} else {
+				panic(p.newError(fmt.Sprintf("unknown constraint %q", constraintName)))
+			}
+		}
+
+		p.typeParameters[name] = parameter
+		parameters = append(parameters, parameter)
+	}
+	p.expect(lexer.GTR)
+
+	return parameters
+}
+
+// lookupType returns the type with the name, including the type parameters
+// of the procedure being parsed
+func (p *Parser) lookupType(name string) types.Type {
+	if parameter, ok := p.typeParameters[name]; ok {
+		return parameter
+	}
+	if typ, ok := p.namedTypes[name]; ok {
+		return typ
+	}
+	if _, ok := p.aliases[name]; ok {
+		return p.alias(name)
+	}
+	if dcl, ok := ast.Universe.LookupLocal(name).(*ast.TypeDeclaration); ok {
+		return dcl.Value
+	}
+
+	return nil
+}
+
+// isType returns true if the name refers to a type
+func (p *Parser) isType(name string) bool {
+	_, basic := ast.Universe.LookupLocal(name).(*ast.TypeDeclaration)
+	return basic || p.typeParameters[name] != nil || p.namedTypes[name] != nil
+}
+
+// declareTypes creates the type for every type declaration before any
+// declaration is parsed, so types can be used before they are declared
+func (p *Parser) declareTypes() {
+	for i := 0; i+2 < len(p.tokens); i++ {
+		if p.tokens[i].Type() != lexer.TYPE || p.tokens[i+1].Type() != lexer.IDENT {
+			continue
+		}
+
+		name := p.tokens[i+1].Value()
+		if _, ok := p.aliases[name]; ok || p.isType(name) {
+			panic(p.newError(fmt.Sprintf("type %q redeclared", name)))
+		}
+
+		switch p.tokens[i+2].Type() {
+		case lexer.ENUM:
+			p.namedTypes[name] = types.NewUnion(name)
+		case lexer.INTERFACE:
+			p.namedTypes[name] = types.NewInterface(name)
+		case lexer.STRUCT:
+			p.namedTypes[name] = types.NewStruct(name)
+		case lexer.ASSIGN:
+			p.aliases[name] = i + 3
+		default:
+			p.namedTypes[name] = types.NewNamed(name)
+		}
+	}
+
+	for name := range p.aliases {
+		p.alias(name)
+	}
+}
+
+// alias returns the type the alias refers to, parsing it if it has not been
+// resolved yet
+func (p *Parser) alias(name string) types.Type {
+	if typ, ok := p.namedTypes[name]; ok {
+		return typ
+	}
+
+	index := p.aliases[name]
+	if index < 0 {
+		panic(p.newError(fmt.Sprintf("invalid recursive type alias %s", name)))
+	}
+
+	// Aliases are resolved out of order so the parser returns to where it was
+	p.aliases[name] = -1
+	saved := p.index
+	p.index = index
+	typ := p.typ()
+	p.index = saved
+
+	p.namedTypes[name] = typ
+	return typ
+}
+
+// signature parses the arguments, return type and body of a procedure
+func (p *Parser) signature() ([]*ast.ArgumentDeclaration, types.Type, *ast.BlockStatement) {
+	arguments := p.argumentList()
+
 	// Get the return type
 	var returnTyp types.Type
 	if p.token().Type() != lexer.LBRACE {
@@ -510,20 +1030,45 @@ func (p *Parser) functionDcl() *ast.FunctionDeclaration {
 		}
 	}
 
-	p.expect(lexer.SEMICOLON)
+	return arguments, returnTyp, block
+}
 
-	funcDcl := &ast.FunctionDeclaration{
-		Name:        name,
-		DoubleColon: colon,
-		Arguments:   arguments,
-		Return:      returnTyp,
-		Body:        block,
+// argumentList parses the arguments of a procedure up to and including the arrow
+func (p *Parser) argumentList() []*ast.ArgumentDeclaration {
+	arguments := []*ast.ArgumentDeclaration{}
+	_, ok := p.accept(lexer.ARROW)
+	for !ok {
+		typ := p.typ()
+		name := p.expect(lexer.IDENT)
+		ident := &ast.IdentExpression{Value: name}
+		arguments = append(arguments, &ast.ArgumentDeclaration{
+			Name: ident,
+			Type: typ,
+		})
+
+		_, ok = p.accept(lexer.ARROW)
+		if !ok {
+			p.expect(lexer.COMMA)
+		}
 	}
 
-	// Insert function into root scope
-	p.insertScope(name.Value.Value(), funcDcl)
+	return arguments
+}
 
-	return funcDcl
+// lambda parses an anonymous procedure, the proc keyword has been consumed
+func (p *Parser) lambda(proc lexer.Token) *ast.LambdaExpression {
+	colon := p.expect(lexer.DOUBLE_COLON)
+	arguments, returnTyp, block := p.signature()
+
+	return &ast.LambdaExpression{
+		Proc: proc,
+		Function: &ast.FunctionDeclaration{
+			DoubleColon: colon,
+			Arguments:   arguments,
+			Return:      returnTyp,
+			Body:        block,
+		},
+	}
 }
 
 func (p *Parser) varibleDcl() *ast.VaribleDeclaration {
@@ -551,40 +1096,394 @@ func (p *Parser) varibleDcl() *ast.VaribleDeclaration {
 	return varDcl
 }
 
+// constantDcl parses a constant declaration, the type is optional
+func (p *Parser) constantDcl() *ast.ConstantDeclaration {
+	constToken := p.expect(lexer.CONST)
+
+	var typ types.Type
+	if p.peek().Type() != lexer.ASSIGN && p.peek().Type() != lexer.DEFINE {
+		typ = p.typ()
+	}
+
+	name := &ast.IdentExpression{
+		Value: p.expect(lexer.IDENT),
+	}
+
+	_, ok := p.accept(lexer.DEFINE)
+	if !ok {
+		p.expect(lexer.ASSIGN)
+	}
+
+	constDcl := &ast.ConstantDeclaration{
+		Const: constToken,
+		Type:  typ,
+		Name:  name,
+		Value: p.expression(0),
+	}
+
+	p.insertScope(name.Value.Value(), constDcl)
+	return constDcl
+}
+
 func (p *Parser) declaration() ast.Declare {
 	switch p.token().Type() {
-	case lexer.PROC:
+	case lexer.PROC, lexer.FUNC:
 		return p.functionDcl()
+	case lexer.CONST:
+		return p.constantDcl()
+	case lexer.TYPE:
+		return p.typeDcl()
 	default:
 		return p.varibleDcl()
 	}
 }
 
+// typeDcl parses a type declaration in the form: type ident enum { variant, ... },
+// type ident interface { method, ... }, type ident struct { field, ... },
+// type ident type or type ident = type. The type was created by declareTypes.
+func (p *Parser) typeDcl() *ast.TypeDeclaration {
+	typeToken := p.expect(lexer.TYPE)
+	name := &ast.IdentExpression{
+		Value: p.expect(lexer.IDENT),
+	}
+
+	typeName := name.Value.Value()
+	typeDcl := &ast.TypeDeclaration{
+		Type:  typeToken,
+		Name:  name,
+		Value: p.lookupType(typeName),
+	}
+
+	switch p.token().Type() {
+	case lexer.ENUM:
+		p.enumType(typeDcl.Value.(*types.Union))
+	case lexer.INTERFACE:
+		p.interfaceType(typeDcl.Value.(*types.Interface))
+	case lexer.STRUCT:
+		p.structType(typeDcl.Value.(*types.Struct))
+	case lexer.ASSIGN:
+		// The aliased type was resolved by declareTypes
+		p.next()
+		p.typ()
+		typeDcl.Alias = true
+	default:
+		p.namedType(typeDcl.Value.(*types.Named))
+	}
+
+	p.insertScope(typeName, typeDcl)
+	return typeDcl
+}
+
+// namedType parses the underlying type of a distinct named type
+func (p *Parser) namedType(named *types.Named) {
+	named.SetUnderlying(p.typ())
+
+	// Named types declared with each other would have no representation
+	for typ := named.Underlying(); typ != nil; {
+		if typ == named {
+			panic(p.newError(fmt.Sprintf("invalid recursive type %s", named)))
+		}
+
+		underlying, ok := typ.(*types.Named)
+		if !ok {
+			break
+		}
+		typ = underlying.Underlying()
+	}
+}
+
+// enumType parses the variants of a union in the form: enum { variant, ... }
+func (p *Parser) enumType(union *types.Union) {
+	p.expect(lexer.ENUM)
+	p.expect(lexer.LBRACE)
+	p.accept(lexer.SEMICOLON)
+	for p.token().Type() != lexer.RBRACE {
+		p.variant(union)
+		p.accept(lexer.COMMA)
+		p.accept(lexer.SEMICOLON)
+	}
+	p.expect(lexer.RBRACE)
+}
+
+// structType parses the fields of a struct in the form: struct { type ident, ... }
+func (p *Parser) structType(structure *types.Struct) {
+	p.expect(lexer.STRUCT)
+	p.expect(lexer.LBRACE)
+	p.accept(lexer.SEMICOLON)
+	for p.token().Type() != lexer.RBRACE {
+		typ := p.typ()
+		if typ == structure {
+			panic(p.newError(fmt.Sprintf("invalid recursive type %s", structure)))
+		}
+
+		field := p.expect(lexer.IDENT).Value()
+		if !structure.AddField(field, typ) {
+			panic(p.newError(fmt.Sprintf("field %q redeclared in %s", field, structure)))
+		}
+
+		p.accept(lexer.COMMA)
+		p.accept(lexer.SEMICOLON)
+	}
+	p.expect(lexer.RBRACE)
+}
+
+// structLiteral parses the values of each field of a struct in the form:
+// type{expression, ...}
+func (p *Parser) structLiteral(typ *types.Struct) *ast.BraceLiteralExpression {
+	leftBrace := p.expect(lexer.LBRACE)
+
+	elements := []ast.Expression{}
+	for p.token().Type() != lexer.RBRACE {
+		// Array fields can be given as untyped array literals
+		var array *types.Array
+		if fields := typ.Types(); len(elements) < len(fields) {
+			array, _ = fields[len(elements)].(*types.Array)
+		}
+
+		if array != nil && p.token().Type() == lexer.LBRACE {
+			elements = append(elements, p.arrayLiteral(array, p.expect(lexer.LBRACE)))
+		} else {
+			elements = append(elements, p.expression(0))
+		}
+		p.accept(lexer.COMMA)
+	}
+
+	return &ast.BraceLiteralExpression{
+		Type:       typ,
+		LeftBrace:  leftBrace,
+		Elements:   elements,
+		RightBrace: p.expect(lexer.RBRACE),
+	}
+}
+
+// interfaceType parses the methods of an interface in the form:
+// interface { ident :: type ident, ... -> type; ... }
+func (p *Parser) interfaceType(iface *types.Interface) {
+	p.expect(lexer.INTERFACE)
+	p.expect(lexer.LBRACE)
+	p.accept(lexer.SEMICOLON)
+	for p.token().Type() != lexer.RBRACE {
+		methodName := p.expect(lexer.IDENT).Value()
+		p.expect(lexer.DOUBLE_COLON)
+
+		var argTypes []types.Type
+		for _, arg := range p.argumentList() {
+			argTypes = append(argTypes, arg.Type)
+		}
+
+		var returnTyp types.Type
+		switch p.token().Type() {
+		case lexer.SEMICOLON, lexer.COMMA, lexer.RBRACE:
+		default:
+			returnTyp = p.typ()
+		}
+
+		method := &types.Method{Name: methodName, Type: types.NewFunction(returnTyp, argTypes...)}
+		if !iface.AddMethod(method) {
+			panic(p.newError(fmt.Sprintf("method %q redeclared in %s", methodName, iface)))
+		}
+
+		p.accept(lexer.COMMA)
+		p.accept(lexer.SEMICOLON)
+	}
+	p.expect(lexer.RBRACE)
+}
+
+// variant parses a variant of a union in the form: ident(type ident, ...), the
+// fields are optional
+func (p *Parser) variant(union *types.Union) {
+	name := p.expect(lexer.IDENT).Value()
+	if _, tag := union.Variant(name); tag >= 0 {
+		panic(p.newError(fmt.Sprintf("variant %q redeclared in %s", name, union)))
+	}
+
+	variant := &types.Variant{Name: name}
+	if _, ok := p.accept(lexer.LPAREN); ok {
+		for ok := p.token().Type() != lexer.RPAREN; ok; _, ok = p.accept(lexer.COMMA) {
+			typ := p.typ()
+			if typ == union {
+				panic(p.newError(fmt.Sprintf("invalid recursive type %s", union)))
+			}
+
+			variant.Types = append(variant.Types, typ)
+			variant.Fields = append(variant.Fields, p.expect(lexer.IDENT).Value())
+		}
+		p.expect(lexer.RPAREN)
+	}
+
+	union.AddVariant(variant)
+}
+
 func (p *Parser) typ() types.Type {
+	// Pointer type
+	if _, ok := p.accept(lexer.MUL); ok {
+		return types.NewPointer(p.typ())
+	}
+
+	// Function type
+	if _, ok := p.accept(lexer.PROC); ok {
+		return p.functionType()
+	}
+
+	// Tuple type
+	if _, ok := p.accept(lexer.LPAREN); ok {
+		elements := []types.Type{p.typ()}
+		for _, ok := p.accept(lexer.COMMA); ok; _, ok = p.accept(lexer.COMMA) {
+			elements = append(elements, p.typ())
+		}
+		p.expect(lexer.RPAREN)
+
+		return types.NewTuple(elements...)
+	}
+
 	ident := p.expect(lexer.IDENT)
-	typ := types.GetType(ident.Value())
+	typ := p.lookupType(ident.Value())
+
+	// Slice and array suffixes, a nil length is a slice
+	var lengths []ast.Expression
+	for _, ok := p.accept(lexer.LBRACK); ok; _, ok = p.accept(lexer.LBRACK) {
+		if _, ok := p.accept(lexer.RBRACK); ok {
+			lengths = append(lengths, nil)
+			continue
+		}
 
-	_, ok := p.accept(lexer.LBRACK)
-	if !ok {
-		return typ
+		lengths = append(lengths, p.expression(0))
+		p.expect(lexer.RBRACK)
 	}
 
-	// TODO: Handle size invalid / constant value
-	sizeToken := p.expect(lexer.INT)
-	size, _ := strconv.Atoi(sizeToken.Value())
-	p.expect(lexer.RBRACK)
+	// i32[3][2] is 3 arrays of 2 i32s, so the last suffix is applied first
+	for i := len(lengths) - 1; i >= 0; i-- {
+		if lengths[i] == nil {
+			typ = types.NewSlice(typ)
+		} else {
+			typ = p.arrayType(typ, lengths[i])
+		}
+	}
 
-	return types.NewArray(typ, int64(size))
+	return typ
+}
+
+// arrayType creates an array of the type, lengths that are not integer
+// literals are evaluated by analysis
+func (p *Parser) arrayType(typ types.Type, length ast.Expression) *types.Array {
+	if literal, ok := length.(*ast.LiteralExpression); ok && literal.Value.Type() == lexer.INT {
+		size, err := strconv.ParseInt(literal.Value.Value(), 0, 64)
+		if err != nil {
+			panic(p.newError(fmt.Sprintf("invalid array length %s", literal.Value.Value())))
+		}
+		return types.NewArray(typ, size)
+	}
+
+	array := types.NewArray(typ, -1)
+	p.lengths = append(p.lengths, &ast.ArrayLength{
+		Array:  array,
+		Length: length,
+	})
+	return array
+}
+
+// arrayLiteral parses the elements of an array literal in the form:
+// { expression, ... }, the elements of nested arrays can be brace literals
+// without a type
+func (p *Parser) arrayLiteral(typ *types.Array, leftBrace lexer.Token) *ast.BraceLiteralExpression {
+	nested, isNested := typ.Type().(*types.Array)
+
+	elements := []ast.Expression{}
+	for p.token().Type() != lexer.RBRACE {
+		if isNested && p.token().Type() == lexer.LBRACE {
+			elements = append(elements, p.arrayLiteral(nested, p.expect(lexer.LBRACE)))
+		} else {
+			elements = append(elements, p.expression(0))
+		}
+		p.accept(lexer.COMMA)
+	}
+
+	return &ast.BraceLiteralExpression{
+		Type:       typ,
+		LeftBrace:  leftBrace,
+		Elements:   elements,
+		RightBrace: p.expect(lexer.RBRACE),
+	}
+}
+
+// functionType parses the argument and return types of a function type in the
+// form: proc(type, type -> type)
+func (p *Parser) functionType() types.Type {
+	p.expect(lexer.LPAREN)
+
+	var argTypes []types.Type
+	for p.token().Type() != lexer.ARROW && p.token().Type() != lexer.RPAREN {
+		argTypes = append(argTypes, p.typ())
+		if _, ok := p.accept(lexer.COMMA); !ok {
+			break
+		}
+	}
+
+	var returnTyp types.Type
+	if _, ok := p.accept(lexer.ARROW); ok {
+		returnTyp = p.typ()
+	}
+	p.expect(lexer.RPAREN)
+
+	return types.NewFunction(returnTyp, argTypes...)
+}
+
+// declareProcedures records the name of every procedure before any
+// declaration is parsed, so calls before the declaration are not mistaken
+// for the builtin it shadows
+func (p *Parser) declareProcedures() {
+	for i := 0; i+1 < len(p.tokens); i++ {
+		switch p.tokens[i].Type() {
+		case lexer.PROC, lexer.FUNC:
+			if p.tokens[i+1].Type() == lexer.IDENT {
+				p.procedures[p.tokens[i+1].Value()] = true
+			}
+		}
+	}
+}
+
+// allocation returns true if the name refers to the new or make builtin
+// rather than a procedure or varible declared by the program
+func (p *Parser) allocation(name string) bool {
+	if name != "new" && name != "make" {
+		return false
+	}
+	if p.procedures[name] {
+		return false
+	}
+
+	if p.scope == nil {
+		return true
+	}
+
+	_, ok := p.scope.Lookup(name).(*ast.Builtin)
+	return ok
 }
 
 func (p *Parser) Parse() *ast.Ast {
 	var functions []*ast.FunctionDeclaration
+	var globals []ast.Declare
+	var typeDcls []*ast.TypeDeclaration
+	p.declareTypes()
+	p.declareProcedures()
 	for !p.eof() {
-		functions = append(functions, p.declaration().(*ast.FunctionDeclaration))
+		switch dcl := p.declaration().(type) {
+		case *ast.FunctionDeclaration:
+			functions = append(functions, dcl)
+		case *ast.TypeDeclaration:
+			typeDcls = append(typeDcls, dcl)
+			p.expect(lexer.SEMICOLON)
+		default:
+			globals = append(globals, dcl)
+			p.expect(lexer.SEMICOLON)
+		}
 	}
 
 	return &ast.Ast{
 		Functions: functions,
+		Globals:   globals,
+		Types:     typeDcls,
+		Lengths:   p.lengths,
 		Scope:     p.scope,
 	}
 }
@@ -26,6 +26,20 @@ func TestParserTypes(t *testing.T) {
 
 		{`i32[2]`, types.NewArray(types.IntType(32), 2)},
 		{`i64[13]`, types.NewArray(types.IntType(64), 13)},
+
+		{`(i32, i64)`, types.NewTuple(types.IntType(32), types.IntType(64))},
+
+		{`*i32`, types.NewPointer(types.IntType(32))},
+		{`*i32[5]`, types.NewPointer(types.NewArray(types.IntType(32), 5))},
+
+		{`i32[]`, types.NewSlice(types.IntType(32))},
+
+		{`i32[3][2]`, types.NewArray(types.NewArray(types.IntType(32), 2), 3)},
+		{`i32[4][]`, types.NewArray(types.NewSlice(types.IntType(32)), 4)},
+		{`i32[][0x10]`, types.NewSlice(types.NewArray(types.IntType(32), 16))},
+
+		{`proc(i32, i64 -> i32)`, types.NewFunction(types.IntType(32), types.IntType(32), types.IntType(64))},
+		{`proc()`, types.NewFunction(nil)},
 	}
 
 	for _, c := range cases {
@@ -89,6 +103,32 @@ func TestParserExpressions(t *testing.T) {
 			},
 		},
 
+		{
+			`&foo`,
+			&ast.UnaryExpression{
+				Operator: lexer.NewToken(lexer.AND, "", 1, 1),
+				Expression: &ast.IdentExpression{
+					Value: lexer.NewToken(lexer.IDENT, "foo", 1, 2),
+				},
+			},
+		},
+
+		{
+			`*foo + 1`,
+			&ast.BinaryExpression{
+				Left: &ast.UnaryExpression{
+					Operator: lexer.NewToken(lexer.MUL, "", 1, 1),
+					Expression: &ast.IdentExpression{
+						Value: lexer.NewToken(lexer.IDENT, "foo", 1, 2),
+					},
+				},
+				Operator: lexer.NewToken(lexer.ADD, "", 1, 6),
+				Right: &ast.LiteralExpression{
+					Value: lexer.NewToken(lexer.INT, "1", 1, 8),
+				},
+			},
+		},
+
 		{
 			`()`,
 			&ast.ParenLiteralExpression{
@@ -236,6 +276,25 @@ func TestParserExpressions(t *testing.T) {
 				RightBrace: lexer.NewToken(lexer.RBRACE, "", 1, 15),
 			},
 		},
+
+		{
+			`x |> f >> g`,
+			&ast.BinaryExpression{
+				Left: &ast.IdentExpression{
+					Value: lexer.NewToken(lexer.IDENT, "x", 1, 1),
+				},
+				Operator: lexer.NewToken(lexer.PIPE, "", 1, 3),
+				Right: &ast.BinaryExpression{
+					Left: &ast.IdentExpression{
+						Value: lexer.NewToken(lexer.IDENT, "f", 1, 6),
+					},
+					Operator: lexer.NewToken(lexer.SHR, "", 1, 8),
+					Right: &ast.IdentExpression{
+						Value: lexer.NewToken(lexer.IDENT, "g", 1, 11),
+					},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -283,6 +342,119 @@ func TestParserStatements(t *testing.T) {
 			},
 		},
 
+		{
+			`return`,
+			&ast.ReturnStatement{
+				Return: lexer.NewToken(lexer.RETURN, "return", 1, 1),
+			},
+		},
+
+		{
+			`call(123)`,
+			&ast.ExpressionStatement{
+				Expression: &ast.CallExpression{
+					Function: &ast.IdentExpression{
+						Value: lexer.NewToken(lexer.IDENT, "call", 1, 1),
+					},
+					Arguments: &ast.ParenLiteralExpression{
+						LeftParen: lexer.NewToken(lexer.LPAREN, "", 1, 5),
+						Elements: []ast.Expression{
+							&ast.LiteralExpression{
+								Value: lexer.NewToken(lexer.INT, "123", 1, 6),
+							},
+						},
+						RightParen: lexer.NewToken(lexer.RPAREN, "", 1, 9),
+					},
+				},
+			},
+		},
+
+		{
+			`defer free(p)`,
+			&ast.DeferStatement{
+				Defer: lexer.NewToken(lexer.DEFER, "defer", 1, 1),
+				Call: &ast.CallExpression{
+					Function: &ast.IdentExpression{
+						Value: lexer.NewToken(lexer.IDENT, "free", 1, 7),
+					},
+					Arguments: &ast.ParenLiteralExpression{
+						LeftParen: lexer.NewToken(lexer.LPAREN, "", 1, 11),
+						Elements: []ast.Expression{
+							&ast.IdentExpression{
+								Value: lexer.NewToken(lexer.IDENT, "p", 1, 12),
+							},
+						},
+						RightParen: lexer.NewToken(lexer.RPAREN, "", 1, 13),
+					},
+				},
+			},
+		},
+
+		{
+			`a, b = b, a`,
+			&ast.AssignmentStatement{
+				Left: &ast.ParenLiteralExpression{
+					Elements: []ast.Expression{
+						&ast.IdentExpression{
+							Value: lexer.NewToken(lexer.IDENT, "a", 1, 1),
+						},
+						&ast.IdentExpression{
+							Value: lexer.NewToken(lexer.IDENT, "b", 1, 4),
+						},
+					},
+				},
+				Assign: lexer.NewToken(lexer.ASSIGN, "", 1, 6),
+				Right: &ast.ParenLiteralExpression{
+					Elements: []ast.Expression{
+						&ast.IdentExpression{
+							Value: lexer.NewToken(lexer.IDENT, "b", 1, 8),
+						},
+						&ast.IdentExpression{
+							Value: lexer.NewToken(lexer.IDENT, "a", 1, 11),
+						},
+					},
+				},
+			},
+		},
+
+		{
+			`q, r := divmod(7, 2)`,
+			&ast.DeclareStatement{
+				Statement: &ast.TupleDeclaration{
+					Declarations: []*ast.VaribleDeclaration{
+						&ast.VaribleDeclaration{
+							Name: &ast.IdentExpression{
+								Value: lexer.NewToken(lexer.IDENT, "q", 1, 1),
+							},
+						},
+						&ast.VaribleDeclaration{
+							Name: &ast.IdentExpression{
+								Value: lexer.NewToken(lexer.IDENT, "r", 1, 4),
+							},
+						},
+					},
+					Define: lexer.NewToken(lexer.DEFINE, "", 1, 6),
+					Value: &ast.CallExpression{
+						Function: &ast.IdentExpression{
+							Value: lexer.NewToken(lexer.IDENT, "divmod", 1, 9),
+						},
+						Arguments: &ast.ParenLiteralExpression{
+							LeftParen: lexer.NewToken(lexer.LPAREN, "", 1, 15),
+							Elements: []ast.Expression{
+								&ast.LiteralExpression{
+									Value: lexer.NewToken(lexer.INT, "7", 1, 16),
+								},
+								&ast.LiteralExpression{
+									Value: lexer.NewToken(lexer.INT, "2", 1, 19),
+								},
+							},
+							RightParen: lexer.NewToken(lexer.RPAREN, "", 1, 20),
+						},
+					},
+				},
+			},
+		},
+
 		{
 			`{}`,
 			&ast.BlockStatement{
@@ -474,6 +646,88 @@ func TestParserStatements(t *testing.T) {
 				},
 			},
 		},
+		{
+			`switch x {
+case 1, 2:
+	return 3
+default:
+}`,
+			&ast.SwitchStatement{
+				Switch: lexer.NewToken(lexer.SWITCH, "switch", 1, 1),
+				Tag: &ast.IdentExpression{
+					Value: lexer.NewToken(lexer.IDENT, "x", 1, 8),
+				},
+				LeftBrace: lexer.NewToken(lexer.LBRACE, "", 1, 10),
+				Clauses: []*ast.CaseClause{
+					&ast.CaseClause{
+						Case: lexer.NewToken(lexer.CASE, "case", 1, 12),
+						Expressions: []ast.Expression{
+							&ast.LiteralExpression{
+								Value: lexer.NewToken(lexer.INT, "1", 1, 17),
+							},
+							&ast.LiteralExpression{
+								Value: lexer.NewToken(lexer.INT, "2", 1, 20),
+							},
+						},
+						Colon: lexer.NewToken(lexer.COLON, "", 1, 21),
+						Body: &ast.BlockStatement{
+							Statements: []ast.Statement{
+								&ast.ReturnStatement{
+									Return: lexer.NewToken(lexer.RETURN, "return", 2, 2),
+									Result: &ast.LiteralExpression{
+										Value: lexer.NewToken(lexer.INT, "3", 2, 9),
+									},
+								},
+							},
+						},
+					},
+					&ast.CaseClause{
+						Case:  lexer.NewToken(lexer.DEFAULT, "default", 3, 1),
+						Colon: lexer.NewToken(lexer.COLON, "", 3, 8),
+						Body: &ast.BlockStatement{
+							Statements: []ast.Statement{},
+						},
+					},
+				},
+				RightBrace: lexer.NewToken(lexer.RBRACE, "", 3, 10),
+			},
+		},
+
+		{
+			`switch {
+case x < 3:
+	fallthrough
+}`,
+			&ast.SwitchStatement{
+				Switch:    lexer.NewToken(lexer.SWITCH, "switch", 1, 1),
+				LeftBrace: lexer.NewToken(lexer.LBRACE, "", 1, 8),
+				Clauses: []*ast.CaseClause{
+					&ast.CaseClause{
+						Case: lexer.NewToken(lexer.CASE, "case", 1, 10),
+						Expressions: []ast.Expression{
+							&ast.BinaryExpression{
+								Left: &ast.IdentExpression{
+									Value: lexer.NewToken(lexer.IDENT, "x", 1, 15),
+								},
+								Operator: lexer.NewToken(lexer.LSS, "", 1, 17),
+								Right: &ast.LiteralExpression{
+									Value: lexer.NewToken(lexer.INT, "3", 1, 19),
+								},
+							},
+						},
+						Colon: lexer.NewToken(lexer.COLON, "", 1, 20),
+						Body: &ast.BlockStatement{
+							Statements: []ast.Statement{
+								&ast.FallthroughStatement{
+									Fallthrough: lexer.NewToken(lexer.FALLTHROUGH, "fallthrough", 2, 2),
+								},
+							},
+						},
+					},
+				},
+				RightBrace: lexer.NewToken(lexer.RBRACE, "", 3, 1),
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -567,6 +821,24 @@ func TestFunctionDeclarations(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			`func function :: -> int {}`,
+			&ast.FunctionDeclaration{
+				Name: &ast.IdentExpression{
+					Value: lexer.NewToken(lexer.IDENT, "function", 1, 6),
+				},
+				DoubleColon: lexer.NewToken(lexer.DOUBLE_COLON, "", 1, 15),
+				Arguments:   []*ast.ArgumentDeclaration{},
+				Return:      types.GetType("int"),
+				Body: &ast.BlockStatement{
+					LeftBrace:  lexer.NewToken(lexer.LBRACE, "", 1, 25),
+					Statements: []ast.Statement{},
+					RightBrace: lexer.NewToken(lexer.RBRACE, "", 1, 26),
+				},
+				Pure: true,
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -590,12 +862,38 @@ func TestFunctionDeclarations(t *testing.T) {
 		testFunc(c.ast.DoubleColon, tree.(*ast.FunctionDeclaration).DoubleColon)
 		testFunc(c.ast.Arguments, tree.(*ast.FunctionDeclaration).Arguments)
 		testFunc(c.ast.Return, tree.(*ast.FunctionDeclaration).Return)
+		testFunc(c.ast.Pure, tree.(*ast.FunctionDeclaration).Pure)
 		testFunc(c.ast.Body.LeftBrace, tree.(*ast.FunctionDeclaration).Body.LeftBrace)
 		testFunc(c.ast.Body.Statements, tree.(*ast.FunctionDeclaration).Body.Statements)
 		testFunc(c.ast.Body.RightBrace, tree.(*ast.FunctionDeclaration).Body.RightBrace)
 	}
 }
 
+func TestTypeParameters(t *testing.T) {
+	source := `proc max<T: ordered, U> :: T a, U b -> T {}`
+	lexer := lexer.NewLexer([]byte(source))
+	tokens, err := lexer.Lex()
+	if err != nil {
+		t.Error(err)
+	}
+	f := NewParser(tokens, false).declaration().(*ast.FunctionDeclaration)
+
+	typeT := types.NewParameter("T", types.IsOrdered)
+	typeU := types.NewParameter("U", 0)
+
+	if !reflect.DeepEqual(f.TypeParameters, []*types.Parameter{typeT, typeU}) {
+		t.Errorf("Expected type parameters [T U], got %s", pp.Sprint(f.TypeParameters))
+	}
+
+	if !reflect.DeepEqual(f.Arguments[0].Type, typeT) || !reflect.DeepEqual(f.Arguments[1].Type, typeU) {
+		t.Errorf("Expected arguments of type T and U, got %s", pp.Sprint(f.Arguments))
+	}
+
+	if !reflect.DeepEqual(f.Return, typeT) {
+		t.Errorf("Expected return type T, got %s", pp.Sprint(f.Return))
+	}
+}
+
 func TestVaribleDeclarations(t *testing.T) {
 	cases := []struct {
 		source string
@@ -648,3 +946,364 @@ func TestVaribleDeclarations(t *testing.T) {
 		}
 	}
 }
+
+func TestConstantDeclarations(t *testing.T) {
+	cases := []struct {
+		source string
+		ast    *ast.ConstantDeclaration
+	}{
+		{
+			`const size = 10`,
+			&ast.ConstantDeclaration{
+				Const: lexer.NewToken(lexer.CONST, "const", 1, 1),
+				Name: &ast.IdentExpression{
+					Value: lexer.NewToken(lexer.IDENT, "size", 1, 7),
+				},
+				Value: &ast.LiteralExpression{
+					Value: lexer.NewToken(lexer.INT, "10", 1, 14),
+				},
+			},
+		},
+		{
+			`const i8 size = 10`,
+			&ast.ConstantDeclaration{
+				Const: lexer.NewToken(lexer.CONST, "const", 1, 1),
+				Type:  types.GetType("i8"),
+				Name: &ast.IdentExpression{
+					Value: lexer.NewToken(lexer.IDENT, "size", 1, 10),
+				},
+				Value: &ast.LiteralExpression{
+					Value: lexer.NewToken(lexer.INT, "10", 1, 17),
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.NewLexer([]byte(c.source)).Lex()
+		if err != nil {
+			t.Error(err)
+		}
+
+		tree := NewParser(tokens, false).declaration()
+		if !reflect.DeepEqual(c.ast, tree) {
+			t.Errorf("Source:\n%q\nExpected:\n%s\nGot:\n%s\n",
+				c.source, pp.Sprint(c.ast), pp.Sprint(tree))
+		}
+	}
+}
+
+func TestParseGlobals(t *testing.T) {
+	source := `const size = 10
+count := 0
+proc main :: -> i32 {
+	return count
+}`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewParser(tokens, true).Parse()
+	if len(tree.Functions) != 1 {
+		t.Errorf("Expected 1 function, got %d", len(tree.Functions))
+	}
+
+	if len(tree.Globals) != 2 {
+		t.Fatalf("Expected 2 globals, got %d", len(tree.Globals))
+	}
+
+	if _, ok := tree.Globals[0].(*ast.ConstantDeclaration); !ok {
+		t.Errorf("Expected first global to be a constant, got %s", pp.Sprint(tree.Globals[0]))
+	}
+
+	if _, ok := tree.Globals[1].(*ast.VaribleDeclaration); !ok {
+		t.Errorf("Expected second global to be a varible, got %s", pp.Sprint(tree.Globals[1]))
+	}
+
+	if tree.Scope.Lookup("count") != tree.Globals[1] {
+		t.Errorf("Expected count to be in the root scope")
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	source := `type Shape enum { Circle(f64 r), Rect(f64 w, f64 h), Empty }
+proc area :: Shape s -> f64 {
+	match s {
+	case Circle(r):
+		return r
+	default:
+		return 0
+	}
+}`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewParser(tokens, true).Parse()
+	if len(tree.Types) != 1 {
+		t.Fatalf("Expected 1 type, got %d", len(tree.Types))
+	}
+
+	shape := types.NewUnion("Shape")
+	shape.AddVariant(&types.Variant{Name: "Circle", Fields: []string{"r"}, Types: []types.Type{types.FloatType(64)}})
+	shape.AddVariant(&types.Variant{Name: "Rect", Fields: []string{"w", "h"},
+		Types: []types.Type{types.FloatType(64), types.FloatType(64)}})
+	shape.AddVariant(&types.Variant{Name: "Empty"})
+
+	if !reflect.DeepEqual(tree.Types[0].Value, shape) {
+		t.Errorf("Expected %s, got %s", pp.Sprint(shape), pp.Sprint(tree.Types[0].Value))
+	}
+
+	f := tree.Functions[0]
+	if f.Arguments[0].Type != tree.Types[0].Value {
+		t.Errorf("Expected argument to have the declared type, got %s", pp.Sprint(f.Arguments[0].Type))
+	}
+
+	match, ok := f.Body.Statements[0].(*ast.MatchStatement)
+	if !ok {
+		t.Fatalf("Expected match statement, got %s", pp.Sprint(f.Body.Statements[0]))
+	}
+
+	if len(match.Clauses) != 2 || !match.Clauses[1].IsDefault() {
+		t.Fatalf("Expected a case and a default clause, got %s", pp.Sprint(match.Clauses))
+	}
+
+	circle := match.Clauses[0]
+	if circle.Name.Value.Value() != "Circle" || len(circle.Bindings) != 1 ||
+		circle.Bindings[0].Value.Value() != "r" {
+		t.Errorf("Expected case Circle(r), got %s", pp.Sprint(circle))
+	}
+
+	if circle.Body.Scope.LookupLocal("r") == nil {
+		t.Errorf("Expected binding r in the clause scope")
+	}
+}
+
+func TestParseInterface(t *testing.T) {
+	source := `type Shape enum { Circle(f64 r) }
+type Measured interface { area :: -> f64, scale :: f64 k -> f64 }
+proc (Shape s) area :: -> f64 {
+	return 0
+}`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewParser(tokens, true).Parse()
+	if len(tree.Types) != 2 {
+		t.Fatalf("Expected 2 types, got %d", len(tree.Types))
+	}
+
+	measured := types.NewInterface("Measured")
+	measured.AddMethod(&types.Method{Name: "area", Type: types.NewFunction(types.FloatType(64))})
+	measured.AddMethod(&types.Method{Name: "scale",
+		Type: types.NewFunction(types.FloatType(64), types.FloatType(64))})
+
+	if !reflect.DeepEqual(tree.Types[1].Value, measured) {
+		t.Errorf("Expected %s, got %s", pp.Sprint(measured), pp.Sprint(tree.Types[1].Value))
+	}
+
+	f := tree.Functions[0]
+	if f.Receiver == nil || f.Receiver.Type != tree.Types[0].Value || f.Receiver.Name.Value.Value() != "s" {
+		t.Fatalf("Expected receiver Shape s, got %s", pp.Sprint(f.Receiver))
+	}
+
+	if f.Body.Scope.LookupLocal("s") == nil {
+		t.Errorf("Expected receiver s in the body scope")
+	}
+}
+
+func TestParseStruct(t *testing.T) {
+	source := `type Vec struct { f64 x, f64 y }
+proc main :: -> i32 {
+	v := Vec{1.0, 2.0}
+	v.x = 3.0
+	v.scale(2.0)
+	return 0
+}`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewParser(tokens, true).Parse()
+
+	vec := types.NewStruct("Vec")
+	vec.AddField("x", types.FloatType(64))
+	vec.AddField("y", types.FloatType(64))
+
+	if !reflect.DeepEqual(tree.Types[0].Value, vec) {
+		t.Errorf("Expected %s, got %s", pp.Sprint(vec), pp.Sprint(tree.Types[0].Value))
+	}
+
+	statements := tree.Functions[0].Body.Statements
+	dcl := statements[0].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	if literal, ok := dcl.Value.(*ast.BraceLiteralExpression); !ok || literal.Type != tree.Types[0].Value ||
+		len(literal.Elements) != 2 {
+		t.Errorf("Expected struct literal, got %s", pp.Sprint(dcl.Value))
+	}
+
+	assignment, ok := statements[1].(*ast.AssignmentStatement)
+	if !ok {
+		t.Fatalf("Expected field assignment, got %s", pp.Sprint(statements[1]))
+	}
+	if _, ok := assignment.Left.(*ast.SelectorExpression); !ok {
+		t.Errorf("Expected selector on the left of the assignment, got %s", pp.Sprint(assignment.Left))
+	}
+
+	call, ok := statements[2].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Expected method call statement, got %s", pp.Sprint(statements[2]))
+	}
+	if _, ok := call.Expression.(*ast.CallExpression).Function.(*ast.SelectorExpression); !ok {
+		t.Errorf("Expected call of a selector, got %s", pp.Sprint(call.Expression))
+	}
+}
+
+func TestParseStructArrayField(t *testing.T) {
+	source := `type Board struct { i32[2][2] cells, i32 moves }
+proc main :: -> i32 {
+	b := Board{{{1, 2}, {3, 4}}, 0}
+	return 0
+}`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewParser(tokens, true).Parse()
+	board := tree.Types[0].Value.(*types.Struct)
+
+	dcl := tree.Functions[0].Body.Statements[0].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration)
+	cells, ok := dcl.Value.(*ast.BraceLiteralExpression).Elements[0].(*ast.BraceLiteralExpression)
+	if !ok || cells.Type != board.Types()[0] || len(cells.Elements) != 2 {
+		t.Fatalf("Expected array literal for the cells field, got %s", pp.Sprint(dcl.Value))
+	}
+
+	if row, ok := cells.Elements[1].(*ast.BraceLiteralExpression); !ok || len(row.Elements) != 2 {
+		t.Errorf("Expected nested array literal, got %s", pp.Sprint(cells.Elements[1]))
+	}
+}
+
+func TestParseTypeDeclarations(t *testing.T) {
+	source := `proc (Celsius c) warm :: -> Celsius {
+	return c + 1
+}
+type Celsius Temperature
+type Temperature f64
+type Id = Key
+type Key = i64[2]`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewParser(tokens, true).Parse()
+	if len(tree.Types) != 4 {
+		t.Fatalf("Expected 4 types, got %d", len(tree.Types))
+	}
+
+	celsius, ok := tree.Types[0].Value.(*types.Named)
+	if !ok || celsius.Underlying() != tree.Types[1].Value {
+		t.Errorf("Expected Celsius to be declared with Temperature, got %s", pp.Sprint(tree.Types[0].Value))
+	}
+
+	if f := tree.Functions[0]; f.Receiver.Type != celsius || f.Return != celsius {
+		t.Errorf("Expected the method to use the type declared after it, got %s", pp.Sprint(f.Receiver))
+	}
+
+	if !reflect.DeepEqual(types.Underlying(celsius), types.FloatType(64)) {
+		t.Errorf("Expected Celsius to be represented as f64, got %s", types.Underlying(celsius))
+	}
+
+	key := types.NewArray(types.IntType(64), 2)
+	for _, dcl := range tree.Types[2:] {
+		if !dcl.Alias || !reflect.DeepEqual(dcl.Value, key) {
+			t.Errorf("Expected %s to be an alias of %s, got %s", dcl.Name.Value.Value(), key, dcl.Value)
+		}
+	}
+}
+
+func TestTypeDeclarationErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{"redeclared type", "type A f64\ntype A i32"},
+		{"redeclared basic type", "type i32 f64"},
+		{"recursive named types", "type A B\ntype B A"},
+		{"recursive alias", "type A = B\ntype B = A[2]"},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.NewLexer([]byte(c.source)).Lex()
+		if err != nil {
+			t.Error(err)
+		}
+
+		func() {
+			defer func() {
+				if _, ok := recover().(*Error); !ok {
+					t.Errorf("Expected %s to cause a parser error", c.name)
+				}
+			}()
+
+			NewParser(tokens, true).Parse()
+		}()
+	}
+}
+
+func TestParseArrayLiterals(t *testing.T) {
+	source := `const N = 2
+proc main :: -> i32 {
+	m := i32[N + 1][2]{{1, 2}, {3, 4}, {5, 6}}
+	m[2][1] = 7
+	return 0
+}`
+
+	tokens, err := lexer.NewLexer([]byte(source)).Lex()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tree := NewParser(tokens, true).Parse()
+	statements := tree.Functions[0].Body.Statements
+
+	literal := statements[0].(*ast.DeclareStatement).Statement.(*ast.VaribleDeclaration).
+		Value.(*ast.BraceLiteralExpression)
+	array := literal.Type.(*types.Array)
+	row := types.NewArray(types.IntType(32), 2)
+	if !reflect.DeepEqual(array.Type(), row) {
+		t.Errorf("Expected array of %s, got %s", row, array.Type())
+	}
+
+	if len(tree.Lengths) != 1 || tree.Lengths[0].Array != array {
+		t.Fatalf("Expected the length of the outer array to be evaluated later, got %s", pp.Sprint(tree.Lengths))
+	}
+
+	if len(literal.Elements) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(literal.Elements))
+	}
+	for _, element := range literal.Elements {
+		nested, ok := element.(*ast.BraceLiteralExpression)
+		if !ok || nested.Type != array.Type() || len(nested.Elements) != 2 {
+			t.Errorf("Expected a row literal, got %s", pp.Sprint(element))
+		}
+	}
+
+	assignment := statements[1].(*ast.AssignmentStatement)
+	if index, ok := assignment.Left.(*ast.IndexExpression); !ok {
+		t.Errorf("Expected assignment to an index, got %s", pp.Sprint(assignment.Left))
+	} else if _, ok := index.Expression.(*ast.IndexExpression); !ok {
+		t.Errorf("Expected chained index, got %s", pp.Sprint(index.Expression))
+	}
+}
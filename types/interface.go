@@ -0,0 +1,105 @@
+package types
+
+import (
+	goorytypes "github.com/bongo227/goory/types"
+)
+
+// Method is a procedure declared on a named type, the type does not include
+// the receiver
+type Method struct {
+	Name string
+	Type *Function
+}
+
+// MethodSet is the methods of a named type, or the methods an interface requires
+type MethodSet struct {
+	methods []*Method
+}
+
+// AddMethod adds the method to the set, returning false if a method with the
+// same name is already in the set
+func (m *MethodSet) AddMethod(method *Method) bool {
+	if existing, _ := m.Method(method.Name); existing != nil {
+		return false
+	}
+
+	m.methods = append(m.methods, method)
+	return true
+}
+
+// Method returns the method with the name and its position in the set, the
+// position is -1 if the set has no such method
+func (m *MethodSet) Method(name string) (*Method, int) {
+	for i, method := range m.methods {
+		if method.Name == name {
+			return method, i
+		}
+	}
+
+	return nil, -1
+}
+
+func (m *MethodSet) Methods() []*Method {
+	return m.methods
+}
+
+// Interface is a set of methods, any named type with the methods can be used
+// as the interface
+type Interface struct {
+	MethodSet
+	name string
+}
+
+func NewInterface(name string) *Interface {
+	return &Interface{name: name}
+}
+
+func (i *Interface) String() string {
+	return i.name
+}
+
+func (i *Interface) Name() string {
+	return i.name
+}
+
+func (i *Interface) Base() Type { return i }
+
+// Llvm returns the interface value { data pointer, vtable pointer }, the data
+// is a copy of the value the interface was created from
+func (i *Interface) Llvm() goorytypes.Type {
+	return goorytypes.NewStructType(
+		goorytypes.NewPointerType(goorytypes.NewIntType(8)),
+		goorytypes.NewPointerType(i.Vtable()))
+}
+
+// Vtable returns the type of the table of methods for a type used as the
+// interface, each method takes the data pointer before its arguments
+func (i *Interface) Vtable() goorytypes.Type {
+	methods := make([]goorytypes.Type, len(i.methods))
+	for j, method := range i.methods {
+		methods[j] = goorytypes.NewPointerType(method.Type.Signature())
+	}
+
+	return goorytypes.NewStructType(methods...)
+}
+
+// Implements returns true if the type has every method of the interface with
+// the same signature, otherwise the name of the first missing method
+func Implements(typ Type, iface *Interface) (string, bool) {
+	named, hasMethods := typ.(interface {
+		Method(name string) (*Method, int)
+	})
+
+	for _, required := range iface.methods {
+		if !hasMethods {
+			return required.Name, false
+		}
+
+		method, _ := named.Method(required.Name)
+		if method == nil || method.Type.String() != required.Type.String() {
+			return required.Name, false
+		}
+	}
+
+	return "", true
+}
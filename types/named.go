@@ -0,0 +1,54 @@
+package types
+
+import goorytypes "github.com/bongo227/goory/types"
+
+// Named is a distinct type with the same representation as its underlying
+// type, values must be converted explicitly between the two
+type Named struct {
+	MethodSet
+	name       string
+	underlying Type
+}
+
+// NewNamed creates a named type, the underlying type is set once declared so
+// named types can be used before their declaration
+func NewNamed(name string) *Named {
+	return &Named{name: name}
+}
+
+// SetUnderlying sets the type the named type was declared with
+func (n *Named) SetUnderlying(typ Type) {
+	n.underlying = typ
+}
+
+// Underlying returns the type the named type was declared with, which may be
+// another named type
+func (n *Named) Underlying() Type {
+	return n.underlying
+}
+
+func (n *Named) String() string {
+	return n.name
+}
+
+func (n *Named) Name() string {
+	return n.name
+}
+
+func (n *Named) Base() Type { return n }
+
+func (n *Named) Llvm() goorytypes.Type {
+	return Underlying(n).Llvm()
+}
+
+// Underlying returns the type with every named type replaced by the type it
+// was declared with
+func Underlying(typ Type) Type {
+	for {
+		named, ok := typ.(*Named)
+		if !ok {
+			return typ
+		}
+		typ = named.underlying
+	}
+}
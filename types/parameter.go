@@ -0,0 +1,103 @@
+package types
+
+import (
+	goorytypes "github.com/bongo227/goory/types"
+)
+
+// Parameter is a type parameter of a generic procedure, it is replaced by a
+// type argument when the procedure is instantiated
+type Parameter struct {
+	name       string
+	constraint BasicInfo
+	iface      *Interface
+}
+
+// NewParameter creates a type parameter, type arguments must have one of the
+// constraint flags set. A constraint of zero allows any type.
+func NewParameter(name string, constraint BasicInfo) *Parameter {
+	return &Parameter{name: name, constraint: constraint}
+}
+
+// NewInterfaceParameter creates a type parameter whose type arguments must
+// implement the interface
+func NewInterfaceParameter(name string, iface *Interface) *Parameter {
+	return &Parameter{name: name, iface: iface}
+}
+
+// constraints are the names of the constraints a type parameter can have
+var constraints = map[string]BasicInfo{
+	"any":     0,
+	"numeric": IsNumeric,
+	"ordered": IsOrdered,
+}
+
+// GetConstraint returns the constraint with the name
+func GetConstraint(name string) (BasicInfo, bool) {
+	constraint, ok := constraints[name]
+	return constraint, ok
+}
+
+func (p *Parameter) String() string {
+	return p.name
+}
+
+// Name returns the name the parameter was declared with
+func (p *Parameter) Name() string {
+	return p.name
+}
+
+// Satisfies returns true if the type can be used as an argument for the parameter
+func (p *Parameter) Satisfies(typ Type) bool {
+	if p.iface != nil {
+		_, ok := Implements(typ, p.iface)
+		return ok
+	}
+
+	if p.constraint == 0 {
+		return true
+	}
+
+	basic, ok := Underlying(typ).(*Basic)
+	return ok && basic.Info()&p.constraint != 0
+}
+
+func (p *Parameter) Base() Type { return p }
+
+func (p *Parameter) Llvm() goorytypes.Type {
+	panic("type parameter " + p.name + " has no llvm type")
+}
+
+// Substitute replaces the type parameters in the type with the type arguments
+// of the same name
+func Substitute(typ Type, arguments map[string]Type) Type {
+	switch typ := typ.(type) {
+	case *Parameter:
+		if argument, ok := arguments[typ.name]; ok {
+			return argument
+		}
+	case *Array:
+		return NewArray(Substitute(typ.typ, arguments), typ.length)
+	case *Slice:
+		return NewSlice(Substitute(typ.typ, arguments))
+	case *Pointer:
+		return NewPointer(Substitute(typ.typ, arguments))
+	case *Tuple:
+		elements := make([]Type, len(typ.types))
+		for i, element := range typ.types {
+			elements[i] = Substitute(element, arguments)
+		}
+		return NewTuple(elements...)
+	case *Function:
+		argTypes := make([]Type, len(typ.argTypes))
+		for i, arg := range typ.argTypes {
+			argTypes[i] = Substitute(arg, arguments)
+		}
+		var returnType Type
+		if typ.returnType != nil {
+			returnType = Substitute(typ.returnType, arguments)
+		}
+		return NewFunction(returnType, argTypes...)
+	}
+
+	return typ
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	param := NewParameter("T", IsNumeric)
+	arguments := map[string]Type{"T": IntType(32)}
+
+	cases := []struct {
+		typ      Type
+		expected Type
+	}{
+		{param, IntType(32)},
+		{NewArray(param, 4), NewArray(IntType(32), 4)},
+		{NewPointer(NewSlice(param)), NewPointer(NewSlice(IntType(32)))},
+		{NewFunction(param, param), NewFunction(IntType(32), IntType(32))},
+		{IntType(8), IntType(8)},
+	}
+
+	for _, c := range cases {
+		if typ := Substitute(c.typ, arguments); !reflect.DeepEqual(typ, c.expected) {
+			t.Errorf("Substitute(%s): expected %s, got %s", c.typ, c.expected, typ)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	numeric := NewParameter("T", IsNumeric)
+	if !numeric.Satisfies(FloatType(64)) {
+		t.Errorf("Expected f64 to satisfy numeric")
+	}
+	if numeric.Satisfies(BasicBool) {
+		t.Errorf("Expected bool not to satisfy numeric")
+	}
+	if !NewParameter("T", 0).Satisfies(NewArray(BasicBool, 2)) {
+		t.Errorf("Expected any type to satisfy an unconstrained parameter")
+	}
+}
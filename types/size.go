@@ -0,0 +1,92 @@
+package types
+
+// pointerSize is the size of a pointer in bytes on the target
+const pointerSize = 8
+
+// Sizeof returns the number of bytes a value of the type occupies in memory
+func Sizeof(typ Type) int64 {
+	switch typ := typ.(type) {
+	case *Basic:
+		switch typ.Type() {
+		case Bool, I8, U8:
+			return 1
+		case I16, U16:
+			return 2
+		case I32, U32, Float, F32:
+			return 4
+		case Int, I64, Uint, U64, F64:
+			return 8
+		case String:
+			return pointerSize + 8
+		}
+	case *Array:
+		return typ.length * align(Sizeof(typ.typ), Alignof(typ.typ))
+	case *Pointer:
+		return pointerSize
+	case *Function, *Interface:
+		return pointerSize * 2
+	case *Slice:
+		return pointerSize + 8 + 8
+	case *Tuple:
+		return sizeofFields(typ.types)
+	case *Struct:
+		return sizeofFields(typ.types)
+	case *Union:
+		// The tag is padded to the alignment of the payload words
+		return 8 + typ.payloadSize()
+	case *Named:
+		return Sizeof(Underlying(typ))
+	}
+
+	panic("Sizeof undefined for type: " + typ.String())
+}
+
+// Alignof returns the required alignment in bytes of a value of the type
+func Alignof(typ Type) int64 {
+	switch typ := typ.(type) {
+	case *Array:
+		return Alignof(typ.typ)
+	case *Slice, *Function, *Union, *Interface:
+		return pointerSize
+	case *Tuple:
+		return alignofFields(typ.types)
+	case *Struct:
+		return alignofFields(typ.types)
+	case *Named:
+		return Alignof(Underlying(typ))
+	case *Basic:
+		if typ.Type() == String {
+			return pointerSize
+		}
+	}
+
+	return Sizeof(typ)
+}
+
+// sizeofFields returns the size of a struct with fields of the types, each
+// field is padded to its alignment
+func sizeofFields(fields []Type) int64 {
+	var size int64
+	for _, field := range fields {
+		size = align(size, Alignof(field)) + Sizeof(field)
+	}
+
+	return align(size, alignofFields(fields))
+}
+
+// alignofFields returns the alignment of a struct with fields of the types
+func alignofFields(fields []Type) int64 {
+	var maxAlign int64 = 1
+	for _, field := range fields {
+		if fieldAlign := Alignof(field); fieldAlign > maxAlign {
+			maxAlign = fieldAlign
+		}
+	}
+
+	return maxAlign
+}
+
+// align rounds the size up to a multiple of alignment
+func align(size, alignment int64) int64 {
+	return (size + alignment - 1) / alignment * alignment
+}
@@ -0,0 +1,45 @@
+package types
+
+import "testing"
+
+func TestSizeof(t *testing.T) {
+	shape := NewUnion("Shape")
+	shape.AddVariant(&Variant{Name: "Circle", Fields: []string{"r"}, Types: []Type{FloatType(64)}})
+	shape.AddVariant(&Variant{Name: "Rect", Fields: []string{"w", "h"}, Types: []Type{FloatType(32), FloatType(32)}})
+	shape.AddVariant(&Variant{Name: "Point", Fields: []string{"x", "y", "z"}, Types: []Type{IntType(32), IntType(32), IntType(32)}})
+
+	vec := NewStruct("Vec")
+	vec.AddField("x", FloatType(32))
+	vec.AddField("next", NewPointer(vec))
+	vec.AddField("tag", IntType(8))
+
+	celsius := NewNamed("Celsius")
+	celsius.SetUnderlying(FloatType(32))
+
+	cases := []struct {
+		typ  Type
+		size int64
+	}{
+		{IntType(8), 1},
+		{IntType(32), 4},
+		{IntType(0), 8},
+		{NewArray(IntType(32), 5), 20},
+		{NewPointer(IntType(8)), 8},
+		{NewSlice(IntType(32)), 24},
+		{NewFunction(IntType(32), IntType(32)), 16},
+		{NewTuple(IntType(8), IntType(32)), 8},
+		{NewTuple(IntType(32), IntType(64), IntType(8)), 24},
+		{shape, 24},
+		{NewUnion("Empty"), 8},
+		{vec, 24},
+		{NewStruct("Unit"), 0},
+		{celsius, 4},
+		{NewTuple(IntType(8), celsius), 8},
+	}
+
+	for _, c := range cases {
+		if size := Sizeof(c.typ); size != c.size {
+			t.Errorf("Sizeof(%s): expected %d, got %d", c.typ, c.size, size)
+		}
+	}
+}
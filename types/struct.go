@@ -0,0 +1,68 @@
+package types
+
+import goorytypes "github.com/bongo227/goory/types"
+
+// Struct is a named sequence of fields
+type Struct struct {
+	MethodSet
+	name   string
+	fields []string
+	types  []Type
+}
+
+// NewStruct creates a struct with no fields, they are added once declared so
+// the fields can point to the struct
+func NewStruct(name string) *Struct {
+	return &Struct{name: name}
+}
+
+// AddField adds a field to the end of the struct, returning false if a field
+// with the same name is already in the struct
+func (s *Struct) AddField(name string, typ Type) bool {
+	if _, index := s.Field(name); index >= 0 {
+		return false
+	}
+
+	s.fields = append(s.fields, name)
+	s.types = append(s.types, typ)
+	return true
+}
+
+// Field returns the type of the field with the name and its index, the index
+// is -1 if the struct has no such field
+func (s *Struct) Field(name string) (Type, int) {
+	for i, field := range s.fields {
+		if field == name {
+			return s.types[i], i
+		}
+	}
+
+	return nil, -1
+}
+
+func (s *Struct) Fields() []string {
+	return s.fields
+}
+
+func (s *Struct) Types() []Type {
+	return s.types
+}
+
+func (s *Struct) String() string {
+	return s.name
+}
+
+func (s *Struct) Name() string {
+	return s.name
+}
+
+func (s *Struct) Base() Type { return s }
+
+func (s *Struct) Llvm() goorytypes.Type {
+	fields := make([]goorytypes.Type, len(s.types))
+	for i, typ := range s.types {
+		fields[i] = typ.Llvm()
+	}
+
+	return goorytypes.NewStructType(fields...)
+}
@@ -6,10 +6,6 @@ import (
 	goorytypes "github.com/bongo227/goory/types"
 )
 
-// TODO: when we implement custom type defininitions create a unresolved type.
-// when we run through with ananlysis this type will be substituted with the
-// actual type.
-
 // Type represents a type
 type Type interface {
 	// Gets the base type of the type
@@ -129,7 +125,7 @@ func FloatType(bits int) *Basic {
 
 func IsBasic(ident string) bool {
 	switch ident {
-	case "int", "i8", "i16", "i32", "i64", "float", "f32", "f64":
+	case "int", "i8", "i16", "i32", "i64", "rune", "float", "f32", "f64", "bool", "string":
 		return true
 	default:
 		return false
@@ -145,7 +141,7 @@ func GetType(ident string) *Basic {
 		return IntType(8)
 	case "i16":
 		return IntType(16)
-	case "i32":
+	case "i32", "rune":
 		return IntType(32)
 	case "i64":
 		return IntType(64)
@@ -157,6 +153,8 @@ func GetType(ident string) *Basic {
 		return FloatType(64)
 	case "bool":
 		return BasicBool
+	case "string":
+		return BasicString
 	}
 
 	return nil
@@ -168,6 +166,13 @@ var (
 		info: IsBool,
 		name: "bool",
 	}
+
+	// BasicString is an immutable sequence of UTF-8 encoded bytes
+	BasicString = &Basic{
+		typ:  String,
+		info: IsString,
+		name: "string",
+	}
 )
 
 func (b *Basic) String() string {
@@ -202,6 +207,8 @@ func (b *Basic) String() string {
 		return "f64"
 	case F32:
 		return "f32"
+	case String:
+		return "string"
 	default:
 		return "unkown"
 	}
@@ -232,25 +239,36 @@ func (a *Array) String() string {
 	return fmt.Sprintf("%s[%d]", a.typ.String(), a.length)
 }
 
+// SetLength sets the length of an array declared with a constant expression,
+// the length is -1 until analysis evaluates it
+func (a *Array) SetLength(length int64) {
+	a.length = length
+}
+
 func (a *Array) Length() int {
-	return a.Length()
+	return int(a.length)
 }
 
 func (a *Array) Type() Type {
 	return a.typ
 }
 
-// type Slice struct {
-// 	typ Type
-// }
+// Slice is a view of a heap allocated array with a length and capacity
+type Slice struct {
+	typ Type
+}
 
-// func NewSlice(typ Type) *Slice {
-// 	return &Slice{typ}
-// }
+func NewSlice(typ Type) *Slice {
+	return &Slice{typ}
+}
+
+func (s *Slice) String() string {
+	return fmt.Sprintf("%s[]", s.typ.String())
+}
 
-// func (s *Slice) Type() Type {
-// 	return s.typ
-// }
+func (s *Slice) Type() Type {
+	return s.typ
+}
 
 type Pointer struct {
 	typ Type
@@ -290,6 +308,11 @@ func (b *Basic) Llvm() goorytypes.Type {
 		return goorytypes.NewFloatType()
 	case F64:
 		return goorytypes.NewDoubleType()
+	case String:
+		// { data, length }
+		return goorytypes.NewStructType(
+			goorytypes.NewPointerType(goorytypes.NewIntType(8)),
+			goorytypes.NewIntType(64))
 	default:
 		panic("TODO: finish this")
 	}
@@ -301,10 +324,15 @@ func (b *Array) Llvm() goorytypes.Type {
 	return goorytypes.NewArrayType(b.typ.Llvm(), int(b.length))
 }
 
-// func (b *Slice) Base() Type   { return b.typ }
+func (b *Slice) Base() Type { return b.typ }
 
-// func (b *Slice) Llvm() goorytypes.Type {
-// }
+// Llvm returns the slice header { data pointer, length, capacity }
+func (b *Slice) Llvm() goorytypes.Type {
+	return goorytypes.NewStructType(
+		goorytypes.NewPointerType(b.typ.Llvm()),
+		goorytypes.NewIntType(64),
+		goorytypes.NewIntType(64))
+}
 
 func (b *Pointer) Base() Type { return b.typ }
 
@@ -312,6 +340,44 @@ func (b *Pointer) Llvm() goorytypes.Type {
 	return goorytypes.NewPointerType(b.Base().Llvm())
 }
 
+// Tuple is an ordered list of types, used for procedures with multiple return values
+type Tuple struct {
+	types []Type
+}
+
+func NewTuple(types ...Type) *Tuple {
+	return &Tuple{types}
+}
+
+func (t *Tuple) String() string {
+	typeString := ""
+	for i, typ := range t.types {
+		typeString += typ.String()
+
+		if i != len(t.types)-1 {
+			typeString += ", "
+		}
+	}
+
+	return fmt.Sprintf("(%s)", typeString)
+}
+
+// Types returns the types of each element in the tuple
+func (t *Tuple) Types() []Type {
+	return t.types
+}
+
+func (t *Tuple) Base() Type { return t }
+
+func (t *Tuple) Llvm() goorytypes.Type {
+	types := make([]goorytypes.Type, len(t.types))
+	for i, typ := range t.types {
+		types[i] = typ.Llvm()
+	}
+
+	return goorytypes.NewStructType(types...)
+}
+
 type Function struct {
 	argTypes   []Type
 	returnType Type
@@ -334,6 +400,10 @@ func (b *Function) String() string {
 		}
 	}
 
+	if b.returnType == nil {
+		return fmt.Sprintf("(%s)", argString)
+	}
+
 	return fmt.Sprintf("(%s) %s", argString, b.returnType.String())
 }
 
@@ -341,17 +411,46 @@ func (b *Function) Arguments() []Type {
 	return b.argTypes
 }
 
+// Return returns the return type of the function, nil for procedures with no
+// return value
 func (b *Function) Return() Type {
 	return b.returnType
 }
 
 func (b *Function) Base() Type { return b.returnType }
 
+// Llvm returns the function value { code pointer, environment pointer }
 func (b *Function) Llvm() goorytypes.Type {
-	argTypes := make([]goorytypes.Type, len(b.argTypes))
-	for i, arg := range b.argTypes {
-		argTypes[i] = arg.Llvm()
+	return goorytypes.NewStructType(
+		goorytypes.NewPointerType(b.Signature()),
+		goorytypes.NewPointerType(goorytypes.NewIntType(8)))
+}
+
+// Signature returns the type of the code a function value points to, the
+// environment is passed before the arguments
+func (b *Function) Signature() goorytypes.Type {
+	argTypes := []goorytypes.Type{goorytypes.NewPointerType(goorytypes.NewIntType(8))}
+	if Indirect(b.returnType) {
+		argTypes = append(argTypes, goorytypes.NewPointerType(b.returnType.Llvm()))
+	}
+	for _, arg := range b.argTypes {
+		if Indirect(arg) {
+			argTypes = append(argTypes, goorytypes.NewPointerType(arg.Llvm()))
+		} else {
+			argTypes = append(argTypes, arg.Llvm())
+		}
+	}
+
+	if b.returnType == nil || Indirect(b.returnType) {
+		return goorytypes.NewFunction(goorytypes.NewVoidType(), argTypes...)
 	}
 
 	return goorytypes.NewFunction(b.returnType.Llvm(), argTypes...)
 }
+
+// Indirect returns true if values of the type are passed to procedures as a
+// pointer to a copy and returned through a pointer the caller provides
+func Indirect(typ Type) bool {
+	_, ok := Underlying(typ).(*Array)
+	return ok
+}
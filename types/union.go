@@ -0,0 +1,83 @@
+package types
+
+import goorytypes "github.com/bongo227/goory/types"
+
+// Union is a tagged union, a value holds one of the variants and a tag
+// recording which
+type Union struct {
+	MethodSet
+	name     string
+	variants []*Variant
+}
+
+// Variant is a case of a union with named fields
+type Variant struct {
+	Name   string
+	Fields []string
+	Types  []Type
+}
+
+// NewUnion creates a union with no variants, they are added once declared so
+// the variants can refer to the union
+func NewUnion(name string) *Union {
+	return &Union{name: name}
+}
+
+// AddVariant adds a variant to the union, its tag is its position
+func (u *Union) AddVariant(variant *Variant) {
+	u.variants = append(u.variants, variant)
+}
+
+func (u *Union) String() string {
+	return u.name
+}
+
+func (u *Union) Name() string {
+	return u.name
+}
+
+func (u *Union) Variants() []*Variant {
+	return u.variants
+}
+
+// Variant returns the variant with the name and its tag, the tag is -1 if the
+// union has no such variant
+func (u *Union) Variant(name string) (*Variant, int) {
+	for tag, variant := range u.variants {
+		if variant.Name == name {
+			return variant, tag
+		}
+	}
+
+	return nil, -1
+}
+
+func (u *Union) Base() Type { return u }
+
+// Llvm returns the union as { i32 tag, payload }
+func (u *Union) Llvm() goorytypes.Type {
+	return goorytypes.NewStructType(goorytypes.NewIntType(32), u.Payload())
+}
+
+// Payload returns the storage shared by the fields of every variant, it is
+// made of words so it is aligned for any field
+func (u *Union) Payload() goorytypes.Type {
+	return goorytypes.NewArrayType(goorytypes.NewIntType(64), int(u.payloadSize()/8))
+}
+
+// payloadSize returns the size of the largest variant rounded up to a word
+func (u *Union) payloadSize() int64 {
+	var size int64
+	for _, variant := range u.variants {
+		if variantSize := Sizeof(variant.Tuple()); variantSize > size {
+			size = variantSize
+		}
+	}
+
+	return align(size, 8)
+}
+
+// Tuple returns the fields of the variant as a tuple
+func (v *Variant) Tuple() *Tuple {
+	return NewTuple(v.Types...)
+}